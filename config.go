@@ -6,14 +6,20 @@ package config
 
 import (
 	"bytes"
+	"cmp"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
+	"math"
 	"os"
 	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
 	"syscall"
+	"time"
 
 	"gopkg.in/yaml.v2"
 )
@@ -22,25 +28,56 @@ import (
 type Config struct {
 	Root    any
 	lastErr error
+	safe    *safeRoot
+	pos     map[string]Position // key positions, replaced but never modified
+	sources map[string][]Source // layers that set each key, oldest first
+
+	descriptions map[string]string // flag help text by path
+	positional   []string          // arguments left by Flag() and Args()
+	configFlag   bool              // Args() accepts -config, see Loader.ConfigArgs()
 }
 
 // Error return last error
 func (c *Config) Error() error {
+	if c.safe != nil {
+		c.safe.mu.Lock()
+		defer c.safe.mu.Unlock()
+		return c.safe.lastErr
+	}
 	return c.lastErr
 }
 
 // Get returns a nested config according to a dotted path.
 func (c *Config) Get(path string) (*Config, error) {
-	n, err := Get(c.Root, path)
+	n, err := get("get", c.root(), path)
 	if err != nil {
 		return nil, err
 	}
-	return &Config{Root: n}, nil
+	pos := subPositions(c.positions(), path)
+	sources := c.sourcesBelow(path)
+	if c.safe != nil {
+		sub := newSafe(n)
+		sub.pos, sub.sources = pos, sources
+		return sub, nil
+	}
+	return &Config{Root: n, pos: pos, sources: sources}, nil
 }
 
-// Set a nested config according to a dotted path.
+// Set a nested config according to a dotted path. Configs created by NewSafe
+// store a deep copy of val, so the caller can't modify the snapshot through
+// it.
 func (c *Config) Set(path string, val any) error {
-	return Set(c.Root, path, val)
+	if c.safe != nil {
+		val = copyValue(val)
+	}
+	return c.update(func(root any) (any, error) {
+		root, err := setPath(root, path, val)
+		if err != nil {
+			return nil, err
+		}
+		c.record(path, Source{Kind: "set", Value: val})
+		return root, nil
+	})
 }
 
 // Env fetch data from system env, based on existing config keys.
@@ -49,68 +86,404 @@ func (c *Config) Env() *Config {
 }
 
 // EnvPrefix fetch data from system env using prefix, based on existing config keys.
+//
+// Values are converted to the type of the value they override: ints, floats
+// and bools are parsed, and lists are read as JSON when the value starts
+// with [ or as comma-separated items otherwise. Variables that can't be
+// converted are skipped and reported by Error().
 func (c *Config) EnvPrefix(prefix string) *Config {
+	if err := c.applyEnv(prefix, lookupEnv); err != nil {
+		c.setError(err)
+	}
+	return c
+}
+
+// lookupEnv looks up a variable of the process environment.
+func lookupEnv(name string) (string, Position, bool) {
+	val, exist := syscall.Getenv(name)
+	return val, Position{}, exist
+}
+
+// EnvScan fetch data from all system env variables starting with prefix,
+// creating the keys that don't exist yet. The rest of each variable name is
+// split on separator ("__" if empty) into a path, so that with the prefix
+// "APP" the variable APP_DB__REPLICAS__2__HOST sets "db.replicas.2.host",
+// adding maps and growing lists as needed. An index more than 1024 items
+// past the end of a list is reported as an error. Parts are lowercased
+// unless they match an existing key case-insensitively.
+//
+// Unlike EnvPrefix(), an empty prefix imports every variable of the
+// environment. Values overriding existing keys are converted to the type of
+// the value they override, and the others are stored as strings. Variables
+// that can't be stored, e.g. because their path goes through a scalar, are
+// reported by Error().
+func (c *Config) EnvScan(prefix, separator string) *Config {
+	if err := c.envScan(prefix, separator); err != nil {
+		c.setError(err)
+	}
+	return c
+}
+
+// envScan implements EnvScan.
+func (c *Config) envScan(prefix, separator string) error {
+	if separator == "" {
+		separator = "__"
+	}
 	if prefix != "" {
 		prefix = strings.ToUpper(prefix) + "_"
 	}
+	type envVar struct {
+		name, raw string
+		parts     []string
+	}
+	var vars []envVar
+	for _, kv := range os.Environ() {
+		name, raw, _ := strings.Cut(kv, "=")
+		if rest, ok := strings.CutPrefix(name, prefix); ok && rest != "" {
+			vars = append(vars, envVar{name, raw, strings.Split(rest, separator)})
+		}
+	}
+	// Sort indexes as numbers, so that lists grow in order.
+	slices.SortFunc(vars, func(a, b envVar) int {
+		return comparePaths(a.parts, b.parts)
+	})
 
-	keys := getKeys(c.Root)
-	for _, key := range keys {
-		k := strings.ToUpper(strings.Join(key, "_"))
-		if val, exist := syscall.Getenv(prefix + k); exist {
-			_ = c.Set(strings.Join(key, "."), val)
+	var errs []error
+	_ = c.update(func(root any) (any, error) {
+		for _, v := range vars {
+			name, raw := v.name, v.raw
+			if root == nil {
+				root = map[string]any{}
+			}
+			parts := envScanPath(root, v.parts)
+			if slices.Contains(parts, "") {
+				errs = append(errs, fmt.Errorf("env %s: %w", name, ErrInvalidPath))
+				continue
+			}
+			var path string
+			for _, part := range parts {
+				path = joinPath(path, part)
+			}
+			current, _ := Get(root, path)
+			val, err := coerce(current, raw)
+			if err != nil {
+				err = &PathError{Op: "set", Path: path, Err: err}
+			} else if err = checkListGrowth(root, parts); err == nil {
+				var r any
+				if r, err = set(root, parts, 0, val); err == nil {
+					root = r
+				}
+			}
+			if err != nil {
+				errs = append(errs, fmt.Errorf("env %s: %w", name, err))
+				continue
+			}
+			c.record(path, Source{Kind: "env", Name: name, Value: val})
+		}
+		return root, nil
+	})
+	return errors.Join(errs...)
+}
+
+// maxListGrowth is how far past the end of a list EnvScan() and GNUArgs()
+// may set an item, so that a variable or an argument with a huge index
+// can't allocate a huge list.
+const maxListGrowth = 1024
+
+// checkListGrowth returns an error if setting the path made of parts in
+// root would grow a list by more than maxListGrowth items.
+func checkListGrowth(root any, parts []string) error {
+	node := root
+	var path string
+	for _, part := range parts {
+		path = joinPath(path, part)
+		switch n := node.(type) {
+		case map[string]any:
+			node = n[part]
+			continue
+		case []any, nil:
+		default:
+			return nil
+		}
+		list, _ := node.([]any)
+		i, err := strconv.Atoi(part)
+		if err != nil {
+			return nil
+		}
+		if i >= len(list)+maxListGrowth {
+			return &PathError{
+				Op:   "set",
+				Path: path,
+				Err:  fmt.Errorf("%w: index %d is too far past the end of a list of %d items", ErrInvalidPath, i, len(list)),
+			}
+		}
+		node = nil
+		if i >= 0 && i < len(list) {
+			node = list[i]
 		}
 	}
-	return c
+	return nil
 }
 
-// Flag parse command line arguments, based on existing config keys.
-func (c *Config) Flag() *Config {
-	keys := getKeys(c.Root)
-	hash := map[string]*string{}
-	for _, key := range keys {
-		k := strings.Join(key, "-")
-		hash[k] = new(string)
-		val, _ := c.String(k)
-		flag.StringVar(hash[k], k, val, "")
+// comparePaths orders paths part by part, comparing list indexes as
+// numbers.
+func comparePaths(a, b []string) int {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		x, errX := strconv.Atoi(a[i])
+		y, errY := strconv.Atoi(b[i])
+		switch {
+		case errX == nil && errY == nil && x != y:
+			return cmp.Compare(x, y)
+		case a[i] != b[i]:
+			return strings.Compare(a[i], b[i])
+		}
 	}
+	return cmp.Compare(len(a), len(b))
+}
 
-	flag.Parse()
+// envScanPath returns the path for the parts of a variable name, reusing
+// the case of existing keys.
+func envScanPath(root any, parts []string) []string {
+	node := root
+	path := make([]string, len(parts))
+	for i, part := range parts {
+		path[i] = strings.ToLower(part)
+		m, ok := node.(map[string]any)
+		if !ok {
+			node, _ = get("get", node, path[i])
+			continue
+		}
+		if key, ok := lookupKey(m, part); ok {
+			path[i] = key
+		}
+		node = m[path[i]]
+	}
+	return path
+}
+
+// applyEnv overrides existing keys with the variables found by lookup,
+// named after the keys with the given prefix. Values are converted to the
+// type of the value they override; the variables that can't be converted
+// are left out and reported in the returned error.
+func (c *Config) applyEnv(prefix string, lookup func(name string) (string, Position, bool)) error {
+	if prefix != "" {
+		prefix = strings.ToUpper(prefix) + "_"
+	}
 
-	flag.Visit(func(f *flag.Flag) {
-		name := strings.ReplaceAll(f.Name, "-", ".")
-		_ = c.Set(name, f.Value.String())
+	var errs []error
+	_ = c.update(func(root any) (any, error) {
+		for _, key := range overrideKeys(root) {
+			name := prefix + strings.ToUpper(strings.Join(key, "_"))
+			raw, pos, exist := lookup(name)
+			if !exist {
+				continue
+			}
+			path := strings.Join(key, ".")
+			current, _ := Get(root, path)
+			val, err := coerce(current, raw)
+			if err == nil {
+				root, err = setPath(root, path, val)
+			}
+			if err != nil {
+				errs = append(errs, fmt.Errorf("env %s: %w", name, err))
+				continue
+			}
+			c.record(path, Source{Kind: "env", Name: name, Pos: pos, Value: val})
+		}
+		return root, nil
 	})
+	return errors.Join(errs...)
+}
 
+// overrideKeys returns the keys that environment variables and flags can
+// override: every list and every scalar of source.
+func overrideKeys(source any) [][]string {
+	var lists [][]string
+	var walk func(value any, base []string)
+	walk = func(value any, base []string) {
+		switch value := value.(type) {
+		case map[string]any:
+			for _, k := range sortedKeys(value) {
+				walk(value[k], append(base[:len(base):len(base)], k))
+			}
+		case []any:
+			if len(base) > 0 {
+				lists = append(lists, base)
+			}
+			for i, v := range value {
+				walk(v, append(base[:len(base):len(base)], strconv.Itoa(i)))
+			}
+		}
+	}
+	walk(source, nil)
+	return append(lists, getKeys(source)...)
+}
+
+// coerce converts raw, read from an environment variable or a flag, to the
+// type of the current value it overrides. Lists are read as JSON if raw
+// starts with [, or as comma-separated items otherwise, and their items are
+// converted to the type of the current ones. A map can't be replaced by a
+// raw value.
+func coerce(current any, raw string) (any, error) {
+	switch current := current.(type) {
+	case map[string]any:
+		return nil, typeMismatch("map[string]any", raw)
+	case int:
+		return toInt(raw)
+	case float64:
+		return toFloat64(raw)
+	case bool:
+		return toBool(raw)
+	case []any:
+		if strings.HasPrefix(strings.TrimSpace(raw), "[") {
+			var list []any
+			if err := json.Unmarshal([]byte(raw), &list); err != nil {
+				return nil, fmt.Errorf("%w: %w", ErrTypeMismatch, err)
+			}
+			items, err := normalizeValue(list)
+			if err != nil || len(current) == 0 {
+				return items, err
+			}
+			// Convert each item to the type of the current item at the
+			// same index, or of the first one past the current items.
+			list = items.([]any)
+			for i, item := range list {
+				ref := current[0]
+				if i < len(current) {
+					ref = current[i]
+				}
+				v, err := coerceItem(ref, item)
+				if err != nil {
+					return nil, fmt.Errorf("item %d: %w", i, err)
+				}
+				list[i] = v
+			}
+			return list, nil
+		}
+		var items []any
+		if strings.TrimSpace(raw) != "" {
+			for _, item := range strings.Split(raw, ",") {
+				items = append(items, strings.TrimSpace(item))
+			}
+		}
+		// Convert the items if the current ones all have the same type.
+		for i := 1; i < len(current); i++ {
+			if fmt.Sprintf("%T", current[i]) != fmt.Sprintf("%T", current[0]) {
+				return items, nil
+			}
+		}
+		if len(current) > 0 {
+			for i, item := range items {
+				v, err := coerce(current[0], item.(string))
+				if err != nil {
+					return nil, fmt.Errorf("item %d: %w", i, err)
+				}
+				items[i] = v
+			}
+		}
+		return items, nil
+	}
+	return raw, nil
+}
+
+// coerceItem converts an item of a JSON list to the type of the scalar ref.
+// Maps, lists and items overriding them are left as they are.
+func coerceItem(ref, item any) (any, error) {
+	switch item.(type) {
+	case map[string]any, []any:
+		return item, nil
+	}
+	switch ref.(type) {
+	case int:
+		return toInt(item)
+	case float64:
+		return toFloat64(item)
+	case bool:
+		return toBool(item)
+	case string:
+		if s, err := toString(item); err == nil {
+			return s, nil
+		}
+	}
+	return item, nil
+}
+
+// Flag parse command line arguments, based on existing config keys.
+//
+// Flags are named after dotted paths with dashes instead of dots, typed
+// after the current value and described by Describe(), so -h prints a
+// useful listing and bools can be set with a bare -debug. They are bound to
+// flag.CommandLine with BindFlags(), which leaves alone the flags the
+// program defines itself, and the remaining arguments are returned by
+// Positional(). Calling Flag() on another config binds the flags to it.
+func (c *Config) Flag() *Config {
+	c.BindFlags(flag.CommandLine)
+	flag.Parse()
+	c.setPositional(flag.Args())
 	return c
 }
 
 // Args command line arguments, based on existing config keys.
+//
+// Values are converted to the type of the value they override, like
+// EnvPrefix() does. Parse errors and flags that can't be converted are
+// reported by Error(); -h reports flag.ErrHelp, and Usage() returns the
+// listing to print. The arguments left after the flags are returned by
+// Positional().
 func (c *Config) Args(args ...string) *Config {
 	if len(args) <= 1 {
 		return c
 	}
 
-	keys := getKeys(c.Root)
-	hash := map[string]*string{}
 	f := flag.NewFlagSet(args[0], flag.ContinueOnError)
-	var err bytes.Buffer
-	f.SetOutput(&err)
-	for _, key := range keys {
-		k := strings.Join(key, "-")
-		hash[k] = new(string)
-		val, _ := c.String(k)
-		f.StringVar(hash[k], k, val, "")
-	}
+	f.SetOutput(io.Discard)
+	paths := c.defineFlags(f)
 
-	c.lastErr = f.Parse(args[1:])
+	parseErr := f.Parse(args[1:])
+	c.setPositional(f.Args())
+	c.setError(errors.Join(parseErr, c.setFlags(f.Visit, paths)))
+	return c
+}
 
-	f.Visit(func(f *flag.Flag) {
-		name := strings.ReplaceAll(f.Name, "-", ".")
-		_ = c.Set(name, f.Value.String())
+// setFlags stores the values of all flags visited by visit in a single
+// update, at the paths returned by defineFlags; flags without a path, like
+// -config, are skipped. Values are converted to the type of the value they
+// override; the flags that can't be converted are left out and reported in
+// the returned error.
+func (c *Config) setFlags(visit func(func(*flag.Flag)), paths map[string]string) error {
+	var errs []error
+	_ = c.update(func(root any) (any, error) {
+		visit(func(f *flag.Flag) {
+			path, ok := paths[f.Name]
+			if !ok {
+				return
+			}
+			r, err := c.applyFlag(root, f.Name, path, f.Value.String())
+			if err != nil {
+				errs = append(errs, fmt.Errorf("flag -%s: %w", f.Name, err))
+				return
+			}
+			root = r
+		})
+		return root, nil
 	})
+	return errors.Join(errs...)
+}
 
-	return c
+// applyFlag stores raw, the value of the flag name, at path in root and
+// returns the new root. The value is converted to the type of the value it
+// overrides. It must be called from an update function.
+func (c *Config) applyFlag(root any, name, path, raw string) (any, error) {
+	current, _ := Get(root, path)
+	val, err := coerce(current, raw)
+	if err != nil {
+		return nil, err
+	}
+	if root, err = setPath(root, path, val); err != nil {
+		return nil, err
+	}
+	c.record(path, Source{Kind: "flag", Name: name, Value: val})
+	return root, nil
 }
 
 // Get all keys for given interface
@@ -124,8 +497,8 @@ func getKeys(source any, base ...string) [][]string {
 
 	switch c := source.(type) {
 	case map[string]any:
-		for k, v := range c {
-			keys := getKeys(v, append(nextBase, k)...)
+		for _, k := range sortedKeys(c) {
+			keys := getKeys(c[k], append(nextBase, k)...)
 			acc = append(acc, keys...)
 		}
 	case []any:
@@ -143,15 +516,28 @@ func getKeys(source any, base ...string) [][]string {
 
 // Bool returns a bool according to a dotted path.
 func (c *Config) Bool(path string) (bool, error) {
-	n, err := Get(c.Root, path)
+	n, err := get("bool", c.root(), path)
 	if err != nil {
 		return false, err
 	}
+	value, err := toBool(n)
+	if err != nil {
+		return false, c.pathError("bool", path, err)
+	}
+	return value, nil
+}
+
+// toBool converts a config value to a bool.
+func toBool(n any) (bool, error) {
 	switch n := n.(type) {
 	case bool:
 		return n, nil
 	case string:
-		return strconv.ParseBool(n)
+		v, err := strconv.ParseBool(n)
+		if err != nil {
+			return false, fmt.Errorf("%w: %w", ErrTypeMismatch, err)
+		}
+		return v, nil
 	}
 	return false, typeMismatch("bool or string", n)
 }
@@ -172,17 +558,30 @@ func (c *Config) UBool(path string, defaults ...bool) bool {
 
 // Float64 returns a float64 according to a dotted path.
 func (c *Config) Float64(path string) (float64, error) {
-	n, err := Get(c.Root, path)
+	n, err := get("float64", c.root(), path)
 	if err != nil {
 		return 0, err
 	}
+	value, err := toFloat64(n)
+	if err != nil {
+		return 0, c.pathError("float64", path, err)
+	}
+	return value, nil
+}
+
+// toFloat64 converts a config value to a float64.
+func toFloat64(n any) (float64, error) {
 	switch n := n.(type) {
 	case float64:
 		return n, nil
 	case int:
 		return float64(n), nil
 	case string:
-		return strconv.ParseFloat(n, 64)
+		v, err := strconv.ParseFloat(n, 64)
+		if err != nil {
+			return 0, fmt.Errorf("%w: %w", ErrTypeMismatch, err)
+		}
+		return v, nil
 	}
 	return 0, typeMismatch("float64, int or string", n)
 }
@@ -203,10 +602,19 @@ func (c *Config) UFloat64(path string, defaults ...float64) float64 {
 
 // Int returns an int according to a dotted path.
 func (c *Config) Int(path string) (int, error) {
-	n, err := Get(c.Root, path)
+	n, err := get("int", c.root(), path)
 	if err != nil {
 		return 0, err
 	}
+	value, err := toInt(n)
+	if err != nil {
+		return 0, c.pathError("int", path, err)
+	}
+	return value, nil
+}
+
+// toInt converts a config value to an int.
+func toInt(n any) (int, error) {
 	switch n := n.(type) {
 	case float64:
 		// encoding/json unmarshal numbers into floats, so we compare
@@ -214,7 +622,7 @@ func (c *Config) Int(path string) (int, error) {
 		if i := int(n); fmt.Sprint(i) == fmt.Sprint(n) {
 			return i, nil
 		} else {
-			return 0, fmt.Errorf("value can't be converted to int: %v", n)
+			return 0, fmt.Errorf("%w: value can't be converted to int: %v", ErrTypeMismatch, n)
 		}
 	case int:
 		return n, nil
@@ -222,7 +630,7 @@ func (c *Config) Int(path string) (int, error) {
 		if v, err := strconv.ParseInt(n, 10, 0); err == nil {
 			return int(v), nil
 		} else {
-			return 0, err
+			return 0, fmt.Errorf("%w: %w", ErrTypeMismatch, err)
 		}
 	}
 	return 0, typeMismatch("float64, int or string", n)
@@ -244,14 +652,14 @@ func (c *Config) UInt(path string, defaults ...int) int {
 
 // List returns a []any according to a dotted path.
 func (c *Config) List(path string) ([]any, error) {
-	n, err := Get(c.Root, path)
+	n, err := get("list", c.root(), path)
 	if err != nil {
 		return nil, err
 	}
 	if value, ok := n.([]any); ok {
 		return value, nil
 	}
-	return nil, typeMismatch("[]any", n)
+	return nil, c.pathError("list", path, typeMismatch("[]any", n))
 }
 
 // UList returns a []any according to a dotted path or defaults or []any.
@@ -270,14 +678,14 @@ func (c *Config) UList(path string, defaults ...[]any) []any {
 
 // Map returns a map[string]any according to a dotted path.
 func (c *Config) Map(path string) (map[string]any, error) {
-	n, err := Get(c.Root, path)
+	n, err := get("map", c.root(), path)
 	if err != nil {
 		return nil, err
 	}
 	if value, ok := n.(map[string]any); ok {
 		return value, nil
 	}
-	return nil, typeMismatch("map[string]any", n)
+	return nil, c.pathError("map", path, typeMismatch("map[string]any", n))
 }
 
 // UMap returns a map[string]any according to a dotted path or default or map[string]any.
@@ -296,10 +704,19 @@ func (c *Config) UMap(path string, defaults ...map[string]any) map[string]any {
 
 // String returns a string according to a dotted path.
 func (c *Config) String(path string) (string, error) {
-	n, err := Get(c.Root, path)
+	n, err := get("string", c.root(), path)
 	if err != nil {
 		return "", err
 	}
+	value, err := toString(n)
+	if err != nil {
+		return "", c.pathError("string", path, err)
+	}
+	return value, nil
+}
+
+// toString converts a config value to a string.
+func toString(n any) (string, error) {
 	switch n := n.(type) {
 	case bool, float64, int:
 		return fmt.Sprint(n), nil
@@ -323,8 +740,193 @@ func (c *Config) UString(path string, defaults ...string) string {
 	return ""
 }
 
-// Copy returns a deep copy with given path or without.
+// Duration returns a time.Duration according to a dotted path.
+//
+// Strings are parsed with time.ParseDuration, e.g. "5s" or "1h30m". Bare
+// numbers, including numeric strings, are interpreted as seconds.
+func (c *Config) Duration(path string) (time.Duration, error) {
+	n, err := get("duration", c.root(), path)
+	if err != nil {
+		return 0, err
+	}
+	value, err := toDuration(n)
+	if err != nil {
+		return 0, c.pathError("duration", path, err)
+	}
+	return value, nil
+}
+
+// toDuration converts a config value to a time.Duration.
+func toDuration(n any) (time.Duration, error) {
+	switch n := n.(type) {
+	case int:
+		return time.Duration(n) * time.Second, nil
+	case float64:
+		return time.Duration(n * float64(time.Second)), nil
+	case string:
+		if d, err := time.ParseDuration(n); err == nil {
+			return d, nil
+		}
+		if f, err := strconv.ParseFloat(n, 64); err == nil {
+			return time.Duration(f * float64(time.Second)), nil
+		}
+		return 0, fmt.Errorf("%w: invalid duration %q", ErrTypeMismatch, n)
+	}
+	return 0, typeMismatch("float64, int or string", n)
+}
+
+// UDuration returns a time.Duration according to a dotted path or default value or 0.
+func (c *Config) UDuration(path string, defaults ...time.Duration) time.Duration {
+	value, err := c.Duration(path)
+
+	if err == nil {
+		return value
+	}
+
+	for _, def := range defaults {
+		return def
+	}
+	return 0
+}
+
+// byteUnits maps the lowercase size suffixes accepted by Bytes() to their
+// multipliers.
+var byteUnits = map[string]float64{
+	"":    1,
+	"b":   1,
+	"kb":  1e3,
+	"mb":  1e6,
+	"gb":  1e9,
+	"tb":  1e12,
+	"pb":  1e15,
+	"kib": 1 << 10,
+	"mib": 1 << 20,
+	"gib": 1 << 30,
+	"tib": 1 << 40,
+	"pib": 1 << 50,
+}
+
+// Bytes returns a size in bytes according to a dotted path.
+//
+// Strings may carry a decimal (KB, MB, GB, TB, PB) or binary (KiB, MiB, GiB,
+// TiB, PiB) unit, e.g. "10MB" or "512MiB". Units are case-insensitive and
+// bare numbers are interpreted as bytes.
+func (c *Config) Bytes(path string) (int64, error) {
+	n, err := get("bytes", c.root(), path)
+	if err != nil {
+		return 0, err
+	}
+	value, err := toBytes(n)
+	if err != nil {
+		return 0, c.pathError("bytes", path, err)
+	}
+	return value, nil
+}
+
+// toBytes converts a config value to a size in bytes.
+func toBytes(n any) (int64, error) {
+	switch n := n.(type) {
+	case int:
+		if n < 0 {
+			return 0, fmt.Errorf("%w: negative size %d", ErrTypeMismatch, n)
+		}
+		return int64(n), nil
+	case float64:
+		i, err := toInt(n)
+		if err != nil {
+			return 0, err
+		}
+		return toBytes(i)
+	case string:
+		s := strings.TrimSpace(n)
+		i := strings.IndexFunc(s, func(r rune) bool {
+			return (r < '0' || r > '9') && r != '.'
+		})
+		if i < 0 {
+			i = len(s)
+		}
+		number, unit := s[:i], strings.ToLower(strings.TrimSpace(s[i:]))
+		f, err := strconv.ParseFloat(number, 64)
+		multiplier, ok := byteUnits[unit]
+		if err != nil || !ok {
+			return 0, fmt.Errorf("%w: invalid size %q", ErrTypeMismatch, n)
+		}
+		size := f * multiplier
+		if size >= math.MaxInt64 {
+			return 0, fmt.Errorf("%w: size %q overflows int64", ErrTypeMismatch, n)
+		}
+		return int64(size), nil
+	}
+	return 0, typeMismatch("float64, int or string", n)
+}
+
+// UBytes returns a size in bytes according to a dotted path or default value or 0.
+func (c *Config) UBytes(path string, defaults ...int64) int64 {
+	value, err := c.Bytes(path)
+
+	if err == nil {
+		return value
+	}
+
+	for _, def := range defaults {
+		return def
+	}
+	return 0
+}
+
+// Time returns a time.Time according to a dotted path. The value must be an
+// RFC 3339 timestamp, e.g. "2006-01-02T15:04:05Z07:00".
+func (c *Config) Time(path string) (time.Time, error) {
+	n, err := get("time", c.root(), path)
+	if err != nil {
+		return time.Time{}, err
+	}
+	value, err := toTime(n)
+	if err != nil {
+		return time.Time{}, c.pathError("time", path, err)
+	}
+	return value, nil
+}
+
+// toTime converts a config value to a time.Time.
+func toTime(n any) (time.Time, error) {
+	if s, ok := n.(string); ok {
+		t, err := time.Parse(time.RFC3339Nano, s)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("%w: %w", ErrTypeMismatch, err)
+		}
+		return t, nil
+	}
+	return time.Time{}, typeMismatch("string", n)
+}
+
+// UTime returns a time.Time according to a dotted path or default value or
+// the zero time.
+func (c *Config) UTime(path string, defaults ...time.Time) time.Time {
+	value, err := c.Time(path)
+
+	if err == nil {
+		return value
+	}
+
+	for _, def := range defaults {
+		return def
+	}
+	return time.Time{}
+}
+
+// Copy returns a deep copy with given path or without. The copy of a config
+// created by NewSafe is thread-safe too.
 func (c *Config) Copy(dottedPath ...string) (*Config, error) {
+	n, err := c.copy(dottedPath...)
+	if err != nil {
+		return nil, err
+	}
+	return c.keepSafe(n), nil
+}
+
+// copy implements Copy, always returning a config that isn't thread-safe.
+func (c *Config) copy(dottedPath ...string) (*Config, error) {
 	var toJoin []string
 	for _, part := range dottedPath {
 		if len(part) != 0 {
@@ -343,10 +945,15 @@ func (c *Config) Copy(dottedPath ...string) (*Config, error) {
 		}
 	}
 
-	if root, err = RenderYaml(cfg.Root); err != nil {
+	if root, err = RenderYaml(cfg.root()); err != nil {
 		return nil, err
 	}
-	return ParseYaml(root)
+	n, err := ParseYaml(root)
+	if err != nil {
+		return nil, err
+	}
+	n.pos, n.sources = cfg.positions(), cfg.sourcesBelow("")
+	return n, nil
 }
 
 // Extend extends the current config with the given config.
@@ -357,16 +964,16 @@ func (c *Config) Copy(dottedPath ...string) (*Config, error) {
 // array, the value from the source config will be used.
 //
 // This is useful for extending a base configuration with additional configuration
-// options.
+// options. Extending a config created by NewSafe returns a thread-safe config.
 func (c *Config) Extend(cfg *Config) (*Config, error) {
 	// First create a deep copy of the current config
-	n, err := c.Copy()
+	n, err := c.copy()
 	if err != nil {
 		return nil, err
 	}
 
 	// Find all arrays in the source config
-	arrayPaths := findArrayPaths(cfg.Root)
+	arrayPaths := findArrayPaths(cfg.root())
 	processedPaths := make(map[string]bool)
 
 	// Process arrays first to ensure they are properly merged
@@ -400,12 +1007,12 @@ func (c *Config) Extend(cfg *Config) (*Config, error) {
 			}
 
 			// Set the merged array in the target config
-			if err := n.Set(path, mergedArr); err != nil {
+			if n.Root, err = setPath(n.Root, path, mergedArr); err != nil {
 				return nil, err
 			}
 		} else {
 			// Target doesn't have an array at this path, just set the source array
-			if err := n.Set(path, sourceArr); err != nil {
+			if n.Root, err = setPath(n.Root, path, sourceArr); err != nil {
 				return nil, err
 			}
 		}
@@ -414,8 +1021,17 @@ func (c *Config) Extend(cfg *Config) (*Config, error) {
 		processedPaths[path] = true
 	}
 
-	// Process all other keys from the source config
-	keys := getKeys(cfg.Root)
+	// Process all other keys from the source config. The history of the
+	// keys set is merged at the end rather than recorded one by one.
+	keys := getKeys(cfg.root())
+	overlay := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		var path string
+		for _, part := range key {
+			path = joinPath(path, part)
+		}
+		overlay[path] = true
+	}
 	for _, key := range keys {
 		k := strings.Join(key, ".")
 
@@ -433,18 +1049,46 @@ func (c *Config) Extend(cfg *Config) (*Config, error) {
 		}
 
 		// Get the value from the source config
-		i, err := Get(cfg.Root, k)
+		i, err := Get(cfg.root(), k)
 		if err != nil {
 			return nil, err
 		}
 
 		// Set the value in the target config
-		if err := n.Set(k, i); err != nil {
+		if n.Root, err = setPath(n.Root, k, i); err != nil {
 			return nil, err
 		}
 	}
 
-	return n, nil
+	// Forget what the base knew about the keys the overlay replaced, and
+	// about the keys that no longer exist.
+	pos := c.positions()
+	base := make(map[string]Position, len(pos))
+	for k, p := range pos {
+		if _, err := Get(n.Root, k); err == nil && !setBelow(overlay, k, true) {
+			base[k] = p
+		}
+	}
+	history := c.sourcesBelow("")
+	for k := range history {
+		if _, err := Get(n.Root, k); err != nil || setBelow(overlay, k, false) {
+			delete(history, k)
+		}
+	}
+	n.pos = mergePositions(base, cfg.positions())
+	n.sources = mergeSources(history, cfg.sourcesBelow(""))
+	return c.keepSafe(n), nil
+}
+
+// keepSafe returns n, a config derived from c, made thread-safe if c is.
+// n must not be used afterwards.
+func (c *Config) keepSafe(n *Config) *Config {
+	if c.safe == nil {
+		return n
+	}
+	s := newSafe(n.Root)
+	s.pos, s.sources = n.pos, n.sources
+	return s
 }
 
 // findArrayPaths finds all paths in the config that are arrays
@@ -473,7 +1117,14 @@ func findArrayPathsRecursive(value any, path string, paths *[]string) {
 
 // typeMismatch returns an error for an expected type.
 func typeMismatch(expected string, got any) error {
-	return fmt.Errorf("type mismatch: expected %s; got %T", expected, got)
+	return fmt.Errorf("%w: expected %s; got %T", ErrTypeMismatch, expected, got)
+}
+
+// pathError returns err as a *PathError for the given operation and path,
+// with the position of the key if it is known.
+func (c *Config) pathError(op, path string, err error) *PathError {
+	pos, _ := c.Position(path)
+	return &PathError{Op: op, Path: path, Err: err, Pos: pos}
 }
 
 // Get returns a value according to a dotted path.
@@ -509,6 +1160,11 @@ func typeMismatch(expected string, got any) error {
 // // err is a type mismatch error, because config["database"]["ports"] is
 // // not a list
 func Get(cfg any, path string) (any, error) {
+	return get("get", cfg, path)
+}
+
+// get implements Get, reporting errors as the given operation.
+func get(op string, cfg any, path string) (any, error) {
 	parts := splitKeyOnParts(path)
 	// Normalize path.
 	for k, v := range parts {
@@ -516,7 +1172,7 @@ func Get(cfg any, path string) (any, error) {
 			if k == 0 {
 				parts = parts[1:]
 			} else {
-				return nil, fmt.Errorf("invalid path %q", path)
+				return nil, &PathError{Op: op, Path: path, Err: ErrInvalidPath}
 			}
 		}
 	}
@@ -528,25 +1184,31 @@ func Get(cfg any, path string) (any, error) {
 				if int(i) < len(c) {
 					cfg = c[i]
 				} else {
-					return nil, fmt.Errorf(
-						"index out of range at %q: list has only %v items",
-						strings.Join(parts[:pos+1], "."), len(c))
+					return nil, &PathError{
+						Op:   op,
+						Path: strings.Join(parts[:pos+1], "."),
+						Err:  fmt.Errorf("%w: list has only %v items", ErrIndexOutOfRange, len(c)),
+					}
 				}
 			} else {
-				return nil, fmt.Errorf("invalid list index at %q",
-					strings.Join(parts[:pos+1], "."))
+				return nil, &PathError{
+					Op:   op,
+					Path: strings.Join(parts[:pos+1], "."),
+					Err:  fmt.Errorf("%w: %q is not a list index", ErrInvalidPath, part),
+				}
 			}
 		case map[string]any:
 			if value, ok := c[part]; ok {
 				cfg = value
 			} else {
-				return nil, fmt.Errorf("nonexistent map key at %q",
-					strings.Join(parts[:pos+1], "."))
+				return nil, &PathError{Op: op, Path: strings.Join(parts[:pos+1], "."), Err: ErrNotFound}
 			}
 		default:
-			return nil, fmt.Errorf(
-				"invalid type at %q: expected []any or map[string]any; got %T",
-				strings.Join(parts[:pos+1], "."), cfg)
+			return nil, &PathError{
+				Op:   op,
+				Path: strings.Join(parts[:pos+1], "."),
+				Err:  typeMismatch("[]any or map[string]any", cfg),
+			}
 		}
 	}
 
@@ -588,13 +1250,23 @@ func splitKeyOnParts(key string) []string {
 // necessary maps or slices are created. This function
 // returns an error if the path is invalid or if a type
 // mismatch occurs at any part of the path.
+//
+// Lists nested in cfg grow as needed, but a list passed as cfg itself can't
+// grow, since the caller's slice can't be replaced.
 func Set(cfg any, path string, value any) error {
+	_, err := setPath(cfg, path, value)
+	return err
+}
+
+// setPath implements Set, returning cfg or, if cfg is a list that had to
+// grow, its replacement.
+func setPath(cfg any, path string, value any) (any, error) {
 	parts := strings.Split(path, ".")
 	// Normalize path.
 	for k, v := range parts {
 		if v == "" {
 			if k != 0 {
-				return fmt.Errorf("invalid path %q", path)
+				return nil, &PathError{Op: "set", Path: path, Err: ErrInvalidPath}
 			}
 
 			parts = parts[1:]
@@ -602,59 +1274,90 @@ func Set(cfg any, path string, value any) error {
 	}
 
 	if len(parts) == 0 {
-		return nil
+		return cfg, nil
 	}
+	return set(cfg, parts, 0, value)
+}
+
+// set implements Set for parts[pos:], where cfg is the value found at
+// parts[:pos]. It returns cfg or, if cfg is a list that had to grow, its
+// replacement.
+func set(cfg any, parts []string, pos int, value any) (any, error) {
+	last := pos == len(parts)-1
 
-	// Handle the root case
 	switch c := cfg.(type) {
 	case map[string]any:
-		if len(parts) == 1 {
-			c[parts[0]] = value
-			return nil
+		if last {
+			c[parts[pos]] = value
+			return c, nil
 		}
-		if v, ok := c[parts[0]]; ok {
-			return Set(v, strings.Join(parts[1:], "."), value)
+		v, ok := c[parts[pos]]
+		if !ok {
+			// If the path doesn't exist, create it
+			if _, err := strconv.Atoi(parts[pos+1]); err == nil {
+				// Next part is a numeric index, create a slice that
+				// grows below
+				v = []any{}
+			} else {
+				// Next part is a string key, create a map
+				v = make(map[string]any)
+			}
 		}
-		// If the path doesn't exist, create it
-		if i, err := strconv.Atoi(parts[1]); err == nil {
-			// Next part is a numeric index, create a slice
-			newSlice := make([]any, i+1)
-			c[parts[0]] = newSlice
-			return Set(newSlice, strings.Join(parts[1:], "."), value)
+		v, err := set(v, parts, pos+1, value)
+		if err != nil {
+			return nil, err
 		}
-		// Next part is a string key, create a map
-		newMap := make(map[string]any)
-		c[parts[0]] = newMap
-		return Set(newMap, strings.Join(parts[1:], "."), value)
+		c[parts[pos]] = v
+		return c, nil
 	case []any:
 		// First part must be a numeric index for slices
-		i, err := strconv.Atoi(parts[0])
+		i, err := strconv.Atoi(parts[pos])
 		if err != nil {
-			return fmt.Errorf("invalid list index at %q", parts[0])
+			return nil, &PathError{
+				Op:   "set",
+				Path: strings.Join(parts[:pos+1], "."),
+				Err:  fmt.Errorf("%w: %q is not a list index", ErrInvalidPath, parts[pos]),
+			}
+		}
+		if i < 0 {
+			return nil, &PathError{
+				Op:   "set",
+				Path: strings.Join(parts[:pos+1], "."),
+				Err:  fmt.Errorf("%w: negative list index %d", ErrInvalidPath, i),
+			}
 		}
 		// Ensure the slice is large enough
 		for len(c) <= i {
 			c = append(c, nil)
 		}
-		if len(parts) == 1 {
+		if last {
 			c[i] = value
-			return nil
+			return c, nil
 		}
 		// If the path doesn't exist or is nil, create it
 		if c[i] == nil {
-			if j, err := strconv.Atoi(parts[1]); err == nil {
-				// Next part is a numeric index, create a slice
-				newSlice := make([]any, j+1)
-				c[i] = newSlice
+			if _, err := strconv.Atoi(parts[pos+1]); err == nil {
+				// Next part is a numeric index, create a slice that
+				// grows below
+				c[i] = []any{}
 			} else {
 				// Next part is a string key, create a map
 				newMap := make(map[string]any)
 				c[i] = newMap
 			}
 		}
-		return Set(c[i], strings.Join(parts[1:], "."), value)
+		v, err := set(c[i], parts, pos+1, value)
+		if err != nil {
+			return nil, err
+		}
+		c[i] = v
+		return c, nil
 	default:
-		return fmt.Errorf("invalid type at root: expected []any or map[string]any; got %T", cfg)
+		return nil, &PathError{
+			Op:   "set",
+			Path: strings.Join(parts[:pos], "."),
+			Err:  typeMismatch("[]any or map[string]any", cfg),
+		}
 	}
 }
 
@@ -680,17 +1383,26 @@ func Must(cfg *Config, err error) *Config {
 // object received from an untrusted source, this function can be used to
 // convert it to a form that can be safely used as a configuration.
 func normalizeValue(value any) (any, error) {
+	return normalizeNode(value, "")
+}
+
+// normalizeNode implements normalizeValue for the value found at path.
+func normalizeNode(value any, path string) (any, error) {
 	switch value := value.(type) {
 	case map[any]any:
 		node := make(map[string]any, len(value))
 		for k, v := range value {
 			key, ok := k.(string)
 			if !ok {
-				return nil, fmt.Errorf("unsupported map key: %#v", k)
+				return nil, &PathError{
+					Op:   "parse",
+					Path: path,
+					Err:  fmt.Errorf("%w: unsupported map key: %#v", ErrTypeMismatch, k),
+				}
 			}
-			item, err := normalizeValue(v)
+			item, err := normalizeNode(v, joinPath(path, key))
 			if err != nil {
-				return nil, fmt.Errorf("unsupported map value: %#v", v)
+				return nil, err
 			}
 			node[key] = item
 		}
@@ -698,9 +1410,9 @@ func normalizeValue(value any) (any, error) {
 	case map[string]any:
 		node := make(map[string]any, len(value))
 		for key, v := range value {
-			item, err := normalizeValue(v)
+			item, err := normalizeNode(v, joinPath(path, key))
 			if err != nil {
-				return nil, fmt.Errorf("unsupported map value: %#v", v)
+				return nil, err
 			}
 			node[key] = item
 		}
@@ -708,17 +1420,63 @@ func normalizeValue(value any) (any, error) {
 	case []any:
 		node := make([]any, len(value))
 		for key, v := range value {
-			item, err := normalizeValue(v)
+			item, err := normalizeNode(v, joinPath(path, strconv.Itoa(key)))
 			if err != nil {
-				return nil, fmt.Errorf("unsupported list item: %#v", v)
+				return nil, err
 			}
 			node[key] = item
 		}
 		return node, nil
 	case bool, float64, int, string, nil:
 		return value, nil
+	case int8:
+		return int(value), nil
+	case int16:
+		return int(value), nil
+	case int32:
+		return int(value), nil
+	case int64:
+		if value < math.MinInt || value > math.MaxInt {
+			return nil, normalizeOverflow(path, value)
+		}
+		return int(value), nil
+	case uint8:
+		return int(value), nil
+	case uint16:
+		return int(value), nil
+	case uint32:
+		if uint64(value) > math.MaxInt {
+			return nil, normalizeOverflow(path, value)
+		}
+		return int(value), nil
+	case uint:
+		if uint64(value) > math.MaxInt {
+			return nil, normalizeOverflow(path, value)
+		}
+		return int(value), nil
+	case uint64:
+		if value > math.MaxInt {
+			return nil, normalizeOverflow(path, value)
+		}
+		return int(value), nil
+	case float32:
+		// Go through the shortest decimal form so that 0.1 stays 0.1.
+		return strconv.ParseFloat(strconv.FormatFloat(float64(value), 'g', -1, 32), 64)
+	}
+	return nil, &PathError{
+		Op:   "parse",
+		Path: path,
+		Err:  fmt.Errorf("%w: unsupported type: %T", ErrTypeMismatch, value),
+	}
+}
+
+// normalizeOverflow returns the error of an integer that doesn't fit an int.
+func normalizeOverflow(path string, value any) error {
+	return &PathError{
+		Op:   "parse",
+		Path: path,
+		Err:  fmt.Errorf("%w: %v overflows int", ErrTypeMismatch, value),
 	}
-	return nil, fmt.Errorf("unsupported type: %T", value)
 }
 
 // ParseJson parses a JSON configuration from the given string.
@@ -729,7 +1487,7 @@ func normalizeValue(value any) (any, error) {
 // The resulting configuration is returned as a *Config, which can be used
 // to access the configuration values.
 func ParseJson(cfg string) (*Config, error) {
-	return parseJson([]byte(cfg))
+	return parseJson([]byte(cfg), "")
 }
 
 // ParseJsonFile reads a JSON configuration from the given filename.
@@ -744,20 +1502,21 @@ func ParseJsonFile(filename string) (*Config, error) {
 	if err != nil {
 		return nil, err
 	}
-	return parseJson(cfg)
+	return parseJson(cfg, filename)
 }
 
-// parseJson performs the real JSON parsing.
-func parseJson(cfg []byte) (*Config, error) {
+// parseJson performs the real JSON parsing, recording key positions in
+// filename.
+func parseJson(cfg []byte, filename string) (*Config, error) {
 	var out any
 	var err error
 	if err = json.Unmarshal(cfg, &out); err != nil {
-		return nil, err
+		return nil, &PathError{Op: "parse", Err: err}
 	}
 	if out, err = normalizeValue(out); err != nil {
 		return nil, err
 	}
-	return &Config{Root: out}, nil
+	return newParsed(out, filename, jsonPositions(cfg, filename)), nil
 }
 
 // RenderJson renders a JSON configuration.
@@ -782,18 +1541,21 @@ func RenderJson(cfg any) (string, error) {
 // The resulting configuration is returned as a *Config, which can be used
 // to access the configuration values.
 func ParseYamlBytes(cfg []byte) (*Config, error) {
-	return parseYaml(cfg)
+	return parseYaml(cfg, "")
 }
 
 // ParseYaml parses a YAML configuration from the given string.
 //
 // The contents of the string should be a valid YAML object. The function
-// will return an error if the YAML is invalid.
+// will return an error if the YAML is invalid. If the string holds several
+// documents separated by ---, the documents not tagged with a profile are
+// merged; see ParseYamlProfile(). In a single document, "profile" is an
+// ordinary key.
 //
 // The resulting configuration is returned as a *Config, which can be used
 // to access the configuration values.
 func ParseYaml(cfg string) (*Config, error) {
-	return parseYaml([]byte(cfg))
+	return parseYaml([]byte(cfg), "")
 }
 
 // ParseYamlFile reads a YAML configuration from the given filename.
@@ -808,7 +1570,7 @@ func ParseYamlFile(filename string) (*Config, error) {
 	if err != nil {
 		return nil, err
 	}
-	return parseYaml(cfg)
+	return parseYaml(cfg, filename)
 }
 
 // RenderYaml marshals the given configuration into a YAML formatted string.
@@ -828,15 +1590,9 @@ func RenderYaml(cfg any) (string, error) {
 	return string(b), nil
 }
 
-// parseYaml performs the real YAML parsing.
-func parseYaml(cfg []byte) (*Config, error) {
-	var out any
-	var err error
-	if err = yaml.Unmarshal(cfg, &out); err != nil {
-		return nil, err
-	}
-	if out, err = normalizeValue(out); err != nil {
-		return nil, err
-	}
-	return &Config{Root: out}, nil
+// parseYaml performs the real YAML parsing, recording key positions in
+// filename. Streams of several documents are merged like
+// ParseYamlProfile() does without a profile.
+func parseYaml(cfg []byte, filename string) (*Config, error) {
+	return parseYamlStream(cfg, filename, "", false)
 }
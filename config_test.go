@@ -6,8 +6,12 @@ package config
 
 import (
 	_ "embed"
+	"flag"
+	"fmt"
 	"os"
+	"strconv"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -314,6 +318,154 @@ func TestEnvPrefix(t *testing.T) {
 	}
 }
 
+func TestEnvPrefixKeepsTypes(t *testing.T) {
+	cfg, err := ParseYaml(`
+server:
+  port: 8080
+  ratio: 0.5
+  debug: false
+  name: app
+  ports: [80, 443]
+  hosts: [a, b]
+`)
+	assert.NoError(t, err)
+
+	t.Setenv("TYPED_SERVER_PORT", "9000")
+	t.Setenv("TYPED_SERVER_RATIO", "1")
+	t.Setenv("TYPED_SERVER_DEBUG", "true")
+	t.Setenv("TYPED_SERVER_NAME", "42")
+	t.Setenv("TYPED_SERVER_PORTS", "8080, 8443")
+	t.Setenv("TYPED_SERVER_HOSTS", `["x", 1]`)
+	cfg.EnvPrefix("typed")
+	assert.NoError(t, cfg.Error())
+
+	out, err := RenderJson(cfg.Root)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"server":{"debug":true,"hosts":["x","1"],"name":"42","port":9000,"ports":[8080,8443],"ratio":1}}`, out)
+
+	t.Setenv("TYPED_SERVER_PORTS", "[3, 4, 5]")
+	cfg.EnvPrefix("typed")
+	assert.NoError(t, cfg.Error())
+	assert.Equal(t, []any{3, 4, 5}, cfg.UList("server.ports"))
+
+	t.Setenv("TYPED_SERVER_PORT", "http")
+	t.Setenv("TYPED_SERVER_PORTS", "80,x")
+	t.Setenv("TYPED_SERVER_DEBUG", "yes please")
+	cfg.EnvPrefix("typed")
+	err = cfg.Error()
+	assert.ErrorIs(t, err, ErrTypeMismatch)
+	assert.ErrorContains(t, err, "env TYPED_SERVER_PORT: type mismatch")
+	assert.ErrorContains(t, err, "env TYPED_SERVER_PORTS: item 1: type mismatch")
+	assert.ErrorContains(t, err, "env TYPED_SERVER_DEBUG: type mismatch")
+	assert.Equal(t, 9000, cfg.Root.(map[string]any)["server"].(map[string]any)["port"])
+
+	t.Setenv("TYPED_SERVER_PORTS", "[80, 1.5]")
+	cfg.EnvPrefix("typed")
+	assert.ErrorContains(t, cfg.Error(), "env TYPED_SERVER_PORTS: item 1: type mismatch: value can't be converted to int: 1.5")
+	assert.Equal(t, []any{3, 4, 5}, cfg.UList("server.ports"))
+}
+
+func TestArgsKeepTypes(t *testing.T) {
+	cfg, err := ParseYaml("port: 8080\ndebug: false\nids: [1, 2]\n")
+	assert.NoError(t, err)
+
+	cfg.Args("app", "-port=9000", "-debug=true", "-ids=3,4,5")
+	assert.NoError(t, cfg.Error())
+	assert.Equal(t, map[string]any{"port": 9000, "debug": true, "ids": []any{3, 4, 5}}, cfg.Root)
+
+	cfg.Args("app", "-debug", "-ids=3,x")
+	assert.ErrorIs(t, cfg.Error(), ErrTypeMismatch)
+	assert.ErrorContains(t, cfg.Error(), "flag -ids: item 1: type mismatch")
+
+	cfg.Args("app", "-port=http")
+	assert.ErrorContains(t, cfg.Error(), `invalid value "http" for flag -port`)
+	assert.Equal(t, 9000, cfg.UInt("port"))
+}
+
+func TestEnvScan(t *testing.T) {
+	cfg, err := ParseYaml(`
+db:
+  maxConns: 10
+  replicas:
+    - host: db1
+    - host: db2
+`)
+	assert.NoError(t, err)
+
+	t.Setenv("SCAN_DB__MAXCONNS", "20")
+	t.Setenv("SCAN_DB__REPLICAS__1__PORT", "5433")
+	t.Setenv("SCAN_DB__REPLICAS__2__HOST", "db3")
+	t.Setenv("SCAN_CACHE__TTL", "1m")
+	t.Setenv("SCANNER_IGNORED", "x")
+	cfg.EnvScan("scan", "")
+	assert.NoError(t, cfg.Error())
+
+	assert.Equal(t, "20", cfg.UString("db.maxConns"))
+	assert.Equal(t, "db2", cfg.UString("db.replicas.1.host"))
+	assert.Equal(t, "5433", cfg.UString("db.replicas.1.port"))
+	assert.Equal(t, "db3", cfg.UString("db.replicas.2.host"))
+	assert.Equal(t, time.Minute, cfg.UDuration("cache.ttl"))
+	_, err = cfg.Get("ner_ignored")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	sources := cfg.Explain("db.replicas.2.host")
+	if assert.Len(t, sources, 1) {
+		assert.Equal(t, "env SCAN_DB__REPLICAS__2__HOST", sources[0].String())
+	}
+
+	t.Setenv("DOT_A_B", "1")
+	cfg.EnvScan("dot", "_")
+	assert.Equal(t, "1", cfg.UString("a.b"))
+
+	t.Setenv("BAD_DB__MAXCONNS__X", "1")
+	t.Setenv("BAD_DB____X", "1")
+	cfg.EnvScan("bad", "__")
+	assert.ErrorIs(t, cfg.Error(), ErrTypeMismatch)
+	assert.ErrorIs(t, cfg.Error(), ErrInvalidPath)
+	assert.ErrorContains(t, cfg.Error(), "env BAD_DB__MAXCONNS__X: ")
+
+	empty := &Config{}
+	empty.EnvScan("scan", "")
+	assert.Equal(t, "db3", empty.UString("db.replicas.2.host"))
+	safe := NewSafe(cfg)
+	safe.EnvScan("scan", "")
+	assert.Equal(t, "db3", safe.UString("db.replicas.2.host"))
+}
+
+func TestSetGrowsNestedList(t *testing.T) {
+	cfg, err := ParseYaml("list: [a, b]\n")
+	assert.NoError(t, err)
+	assert.NoError(t, cfg.Set("list.3", "d"))
+	assert.Equal(t, []any{"a", "b", nil, "d"}, cfg.UList("list"))
+	assert.NoError(t, cfg.Set("nested.0.1", "x"))
+	assert.Equal(t, []any{[]any{nil, "x"}}, cfg.UList("nested"))
+	assert.ErrorIs(t, cfg.Set("list.-1", "e"), ErrInvalidPath)
+	assert.ErrorIs(t, cfg.Set("other.-1", "e"), ErrInvalidPath)
+}
+
+func TestEnvScanLists(t *testing.T) {
+	cfg, err := ParseYaml("db:\n  host: localhost\n")
+	assert.NoError(t, err)
+
+	for i := 0; i < 12; i++ {
+		t.Setenv(fmt.Sprintf("LIST_IDS__%d", i), strconv.Itoa(i))
+	}
+	t.Setenv("LIST_DB", "oops")
+	t.Setenv("LIST_L__99999999999__Y", "1")
+	t.Setenv("LIST_NEW__2__HOST", "db3")
+	cfg.EnvScan("list", "")
+
+	err = cfg.Error()
+	assert.ErrorIs(t, err, ErrTypeMismatch)
+	assert.ErrorContains(t, err, `env LIST_DB: set "db": type mismatch: expected map[string]any; got string`)
+	assert.ErrorIs(t, err, ErrInvalidPath)
+	assert.ErrorContains(t, err, `env LIST_L__99999999999__Y: set "l.99999999999": invalid path: index 99999999999 is too far past the end of a list of 0 items`)
+	assert.Equal(t, []any{nil, nil, map[string]any{"host": "db3"}}, cfg.UList("new"))
+	assert.Equal(t, "localhost", cfg.UString("db.host"))
+	assert.Len(t, cfg.UList("ids"), 12)
+	assert.Equal(t, "11", cfg.UString("ids.11"))
+}
+
 func TestFlag(t *testing.T) {
 	cfg, err := ParseYaml(`
 map:
@@ -333,6 +485,26 @@ map:
 	}
 }
 
+func TestFlagTwice(t *testing.T) {
+	args := os.Args
+	t.Cleanup(func() { os.Args = args })
+	os.Args = []string{args[0], "-twice-port", "9000"}
+
+	first, err := ParseYaml("twice:\n  port: 8080\n")
+	assert.NoError(t, err)
+	first.Flag()
+	assert.Equal(t, 9000, first.UInt("twice.port"))
+
+	os.Args = []string{args[0], "-twice-port", "9001", "-twice-host", "example.com"}
+	second, err := ParseYaml("twice:\n  port: 80\n  host: localhost\n")
+	assert.NoError(t, err)
+	second.Flag()
+	assert.Equal(t, 9001, second.UInt("twice.port"))
+	assert.Equal(t, "example.com", second.UString("twice.host"))
+	assert.Equal(t, 9000, first.UInt("twice.port"))
+	assert.Equal(t, "80", flag.Lookup("twice-port").DefValue)
+}
+
 func TestUMethods(t *testing.T) {
 	cfg, err := ParseYaml(yamlString)
 	if err != nil {
@@ -364,6 +536,122 @@ func TestUMethods(t *testing.T) {
 	assert.Equal(t, 0, cfg.UInt("map.undefined"))
 }
 
+func TestDuration(t *testing.T) {
+	cfg, err := ParseYaml(`
+timeouts:
+  read: 5s
+  write: 1h30m
+  idle: 30
+  grace: 1.5
+  retry: "10"
+  bad: soon
+`)
+	assert.NoError(t, err)
+
+	var durationTests = []struct {
+		path string
+		want time.Duration
+		ok   bool
+	}{
+		{"timeouts.read", 5 * time.Second, true},
+		{"timeouts.write", 90 * time.Minute, true},
+		{"timeouts.idle", 30 * time.Second, true},
+		{"timeouts.grace", 1500 * time.Millisecond, true},
+		{"timeouts.retry", 10 * time.Second, true},
+		{"timeouts.bad", 0, false},
+		{"timeouts", 0, false},
+	}
+	for _, test := range durationTests {
+		got, err := cfg.Duration(test.path)
+		if test.ok {
+			assert.NoError(t, err, test.path)
+			assert.Equal(t, test.want, got, test.path)
+		} else {
+			assert.ErrorIs(t, err, ErrTypeMismatch, test.path)
+		}
+	}
+
+	assert.Equal(t, 5*time.Second, cfg.UDuration("timeouts.read", time.Minute))
+	assert.Equal(t, time.Minute, cfg.UDuration("timeouts.undefined", time.Minute))
+	assert.Equal(t, time.Duration(0), cfg.UDuration("timeouts.bad"))
+}
+
+func TestBytes(t *testing.T) {
+	cfg, err := ParseYaml(`
+sizes:
+  plain: 1024
+  bytes: 100B
+  kb: 10KB
+  mb: 10MB
+  mib: 512MiB
+  gib: 1.5 GiB
+  lower: 2kib
+  float: 2048.0
+  negative: -1
+  bad: lots
+  unit: 10XB
+  huge: 100000PB
+`)
+	assert.NoError(t, err)
+
+	var bytesTests = []struct {
+		path string
+		want int64
+		ok   bool
+	}{
+		{"sizes.plain", 1024, true},
+		{"sizes.bytes", 100, true},
+		{"sizes.kb", 10000, true},
+		{"sizes.mb", 10000000, true},
+		{"sizes.mib", 512 << 20, true},
+		{"sizes.gib", 3 << 29, true},
+		{"sizes.lower", 2048, true},
+		{"sizes.float", 2048, true},
+		{"sizes.negative", 0, false},
+		{"sizes.bad", 0, false},
+		{"sizes.unit", 0, false},
+		{"sizes.huge", 0, false},
+	}
+	for _, test := range bytesTests {
+		got, err := cfg.Bytes(test.path)
+		if test.ok {
+			assert.NoError(t, err, test.path)
+			assert.Equal(t, test.want, got, test.path)
+		} else {
+			assert.ErrorIs(t, err, ErrTypeMismatch, test.path)
+		}
+	}
+
+	assert.Equal(t, int64(1024), cfg.UBytes("sizes.plain", 1))
+	assert.Equal(t, int64(1), cfg.UBytes("sizes.undefined", 1))
+	assert.Equal(t, int64(0), cfg.UBytes("sizes.bad"))
+}
+
+func TestTime(t *testing.T) {
+	cfg, err := ParseYaml(`
+release:
+  date: 2024-03-01T10:30:00Z
+  zoned: "2024-03-01T10:30:00.5+02:00"
+  bad: yesterday
+`)
+	assert.NoError(t, err)
+
+	got, err := cfg.Time("release.date")
+	assert.NoError(t, err)
+	assert.True(t, time.Date(2024, 3, 1, 10, 30, 0, 0, time.UTC).Equal(got))
+
+	got, err = cfg.Time("release.zoned")
+	assert.NoError(t, err)
+	assert.True(t, time.Date(2024, 3, 1, 8, 30, 0, 5e8, time.UTC).Equal(got))
+
+	_, err = cfg.Time("release.bad")
+	assert.ErrorIs(t, err, ErrTypeMismatch)
+
+	def := time.Unix(0, 0)
+	assert.Equal(t, def, cfg.UTime("release.undefined", def))
+	assert.True(t, cfg.UTime("release.bad").IsZero())
+}
+
 func TestCopy(t *testing.T) {
 	cfg, err := ParseYaml(yamlString)
 	if err != nil {
@@ -406,7 +694,8 @@ map:
 	extended, err := cfg.Extend(cfg2)
 	assert.Error(t, err)
 	assert.Nil(t, extended)
-	assert.Equal(t, "invalid list index at \"key0\"", err.Error())
+	assert.ErrorIs(t, err, ErrInvalidPath)
+	assert.Equal(t, "set \"list.key0\": invalid path: \"key0\" is not a list index", err.Error())
 }
 
 var (
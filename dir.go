@@ -0,0 +1,114 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// DirOption configures ParseDir() and ParseGlob().
+type DirOption func(*dirOptions)
+
+type dirOptions struct {
+	nest bool
+}
+
+// NestByFile nests the contents of each file under a key named after the
+// file name without its extension, so that db.yml is found under "db".
+func NestByFile() DirOption {
+	return func(o *dirOptions) {
+		o.nest = true
+	}
+}
+
+// ParseDir parses every .yml, .yaml and .json file in dir, in lexical order,
+// and merges them with Extend() semantics, so that later files override
+// earlier ones. Subdirectories are not read.
+//
+// Example:
+//
+//	// /etc/app/conf.d/00-base.yml, 10-db.yml, 90-local.json
+//	cfg, err := config.ParseDir("/etc/app/conf.d")
+func ParseDir(dir string, opts ...DirOption) (*Config, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, entry := range entries {
+		if !entry.IsDir() && isConfigFile(entry.Name()) {
+			files = append(files, filepath.Join(dir, entry.Name()))
+		}
+	}
+	return parseFiles(os.ReadFile, files, opts)
+}
+
+// ParseGlob parses the files matching pattern, in lexical order, and merges
+// them with Extend() semantics. The format of each file is chosen by its
+// extension. The pattern syntax is the one of filepath.Match.
+//
+// Example:
+//
+//	cfg, err := config.ParseGlob("/etc/app/conf.d/*.yml")
+func ParseGlob(pattern string, opts ...DirOption) (*Config, error) {
+	files, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, err
+	}
+	return parseFiles(os.ReadFile, files, opts)
+}
+
+// isConfigFile reports whether ParseDir() reads the named file.
+func isConfigFile(name string) bool {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".yml", ".yaml", ".json":
+		return true
+	}
+	return false
+}
+
+// parseFiles reads files with readFile, then parses and merges them in
+// order.
+func parseFiles(readFile func(string) ([]byte, error), files []string, opts []DirOption) (*Config, error) {
+	var o dirOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	cfg := &Config{Root: map[string]any{}}
+	for _, filename := range files {
+		file, err := parseByExt(readFile, filename)
+		if err != nil {
+			return nil, err
+		}
+		if o.nest {
+			base := filepath.Base(filename)
+			file = nest(file, strings.TrimSuffix(base, filepath.Ext(base)))
+		}
+		if cfg, err = cfg.Extend(file); err != nil {
+			return nil, err
+		}
+	}
+	return cfg, nil
+}
+
+// nest returns a config holding the contents of cfg under key.
+func nest(cfg *Config, key string) *Config {
+	prefix := joinPath("", key) + "."
+	n := &Config{
+		Root:    map[string]any{key: cfg.root()},
+		pos:     make(map[string]Position),
+		sources: make(map[string][]Source),
+	}
+	for k, p := range cfg.pos {
+		n.pos[prefix+k] = p
+	}
+	for k, history := range cfg.sourcesBelow("") {
+		n.sources[prefix+k] = history
+	}
+	return n
+}
@@ -0,0 +1,74 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"io/fs"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseDir(t *testing.T) {
+	cfg, err := ParseDir("testdata/conf.d")
+	assert.NoError(t, err)
+
+	assert.Equal(t, "localhost", cfg.UString("server.host"))
+	assert.Equal(t, 9000, cfg.UInt("server.port"))
+	assert.True(t, cfg.UBool("server.debug"))
+	assert.Equal(t, []any{"x", "b", "c"}, cfg.UList("ids"))
+	_, err = cfg.Get("ignored")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	pos, ok := cfg.Position("server.port")
+	assert.True(t, ok)
+	assert.Equal(t, "testdata/conf.d/10-port.json", pos.File)
+
+	_, err = ParseDir("testdata/missing")
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+}
+
+func TestParseDirNestByFile(t *testing.T) {
+	cfg, err := ParseDir("testdata/conf.d", NestByFile())
+	assert.NoError(t, err)
+
+	assert.Equal(t, 8080, cfg.UInt("00-base.server.port"))
+	assert.Equal(t, 9000, cfg.UInt("10-port.server.port"))
+	assert.True(t, cfg.UBool("20-debug.server.debug"))
+
+	pos, _ := cfg.Position("20-debug.server.debug")
+	assert.Equal(t, Position{File: "testdata/conf.d/20-debug.yaml", Line: 2, Column: 3}, pos)
+	sources := cfg.Explain("00-base.server.host")
+	if assert.Len(t, sources, 1) {
+		assert.Equal(t, "testdata/conf.d/00-base.yml", sources[0].Name)
+	}
+}
+
+func TestParseGlob(t *testing.T) {
+	cfg, err := ParseGlob("testdata/conf.d/*.y*ml")
+	assert.NoError(t, err)
+	assert.Equal(t, 8080, cfg.UInt("server.port"))
+	assert.True(t, cfg.UBool("server.debug"))
+
+	cfg, err = ParseGlob("testdata/conf.d/none-*.yml")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]any{}, cfg.Root)
+
+	_, err = ParseGlob("testdata/conf.d/*")
+	assert.ErrorContains(t, err, `README.txt: unsupported file format ".txt"`)
+
+	_, err = ParseGlob("testdata/[")
+	assert.Error(t, err)
+}
+
+func TestLoaderDir(t *testing.T) {
+	cfg, err := NewLoader().
+		File("testdata/default.yml").
+		Dir("testdata/conf.d").
+		Load()
+	assert.NoError(t, err)
+	assert.Equal(t, "default", cfg.UString("app.env"))
+	assert.Equal(t, 9000, cfg.UInt("server.port"))
+}
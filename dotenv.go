@@ -0,0 +1,308 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ParseDotenv parses a .env file from the given string.
+//
+// Each line holds a NAME=value assignment, optionally preceded by "export".
+// Blank lines and lines starting with # are ignored. Unquoted values end at
+// the first " #" comment, single-quoted values are taken literally and
+// double-quoted values support the \n, \r, \t, \", \\ and \$ escapes. Quoted
+// values may span multiple lines.
+//
+// Names are lowercased and split on underscores into nested maps, so
+// APP_SERVER_PORT=9000 is found at "app.server.port". A name that is a
+// prefix of another one is kept whole, along with the longer names: with
+// DATABASE=x and DATABASE_URL=y, the keys are "database" and
+// "database_url". All values are strings.
+func ParseDotenv(cfg string) (*Config, error) {
+	return parseDotenv([]byte(cfg), "")
+}
+
+// ParseDotenvFile reads a .env file from the given filename. See ParseDotenv()
+// for the syntax.
+func ParseDotenvFile(filename string) (*Config, error) {
+	cfg, err := os.ReadFile(filepath.Clean(filename))
+	if err != nil {
+		return nil, err
+	}
+	return parseDotenv(cfg, filename)
+}
+
+// RenderDotenv renders a configuration as a .env file, the reverse of
+// ParseDotenv(). Paths are uppercased and joined with underscores, so
+// "app.server.port" becomes APP_SERVER_PORT. Values that need it are
+// double-quoted, and lines are sorted by name.
+//
+// The mapping can't always be reversed: ParseDotenv() splits keys holding
+// underscores, reads lists back as maps keyed by index and all values as
+// strings. Use another format to keep the exact structure.
+func RenderDotenv(cfg any) (string, error) {
+	var lines []string
+	for _, key := range getKeys(cfg) {
+		if len(key) == 0 {
+			return "", typeMismatch("map or list", cfg)
+		}
+		var path string
+		for _, part := range key {
+			path = joinPath(path, part)
+		}
+		value, err := get("render", cfg, path)
+		if err != nil {
+			return "", err
+		}
+		var s string
+		switch value.(type) {
+		case nil, map[string]any, []any:
+			// empty values
+		default:
+			if s, err = toString(value); err != nil {
+				return "", &PathError{Op: "render", Path: path, Err: err}
+			}
+		}
+		lines = append(lines, strings.ToUpper(strings.Join(key, "_"))+"="+quoteDotenv(s)+"\n")
+	}
+	sort.Strings(lines)
+	return strings.Join(lines, ""), nil
+}
+
+// EnvFile fetch data from a .env file using prefix, based on existing config
+// keys, the same way EnvPrefix() does with the process environment. Errors
+// reading or parsing the file are returned by Error().
+//
+// Example:
+//
+//	cfg.EnvFile(".env", "APP").EnvPrefix("APP")
+func (c *Config) EnvFile(filename, prefix string) *Config {
+	if err := c.envFile(filename, prefix); err != nil {
+		c.setError(err)
+	}
+	return c
+}
+
+// envFile implements EnvFile.
+func (c *Config) envFile(filename, prefix string) error {
+	data, err := os.ReadFile(filepath.Clean(filename))
+	if err != nil {
+		return err
+	}
+	vars, err := parseDotenvVars(data, filename)
+	if err != nil {
+		return err
+	}
+	byName := make(map[string]dotenvVar, len(vars))
+	for _, v := range vars {
+		byName[v.name] = v
+	}
+	return c.applyEnv(prefix, func(name string) (string, Position, bool) {
+		v, ok := byName[name]
+		return v.value, v.pos, ok
+	})
+}
+
+// dotenvVar is an assignment read from a .env file.
+type dotenvVar struct {
+	name  string
+	value string
+	pos   Position
+}
+
+// parseDotenv performs the real .env parsing.
+func parseDotenv(cfg []byte, filename string) (*Config, error) {
+	vars, err := parseDotenvVars(cfg, filename)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([][]string, len(vars))
+	for i, v := range vars {
+		for _, part := range strings.Split(strings.ToLower(v.name), "_") {
+			if part != "" {
+				names[i] = append(names[i], part)
+			}
+		}
+		if len(names[i]) == 0 {
+			return nil, &PathError{Op: "parse", Pos: v.pos, Err: fmt.Errorf("invalid name %q", v.name)}
+		}
+	}
+	names = keepPrefixesWhole(names, "_")
+
+	root := map[string]any{}
+	pos := make(map[string]Position)
+	for i, v := range vars {
+		path, err := insertFlat(root, names[i], v.value)
+		if err != nil {
+			return nil, &PathError{Op: "parse", Path: path, Pos: v.pos, Err: err}
+		}
+		pos[path] = v.pos
+	}
+	return newParsed(root, filename, pos), nil
+}
+
+// keepPrefixesWhole returns the parts of each name, joining back with sep
+// the names that are a prefix of another one, like DATABASE and
+// DATABASE_URL, along with the longer names. Split, they would need both a
+// value and a map at the same path.
+func keepPrefixesWhole(names [][]string, sep string) [][]string {
+	whole := make(map[string]bool)
+	prefixes := make(map[string]bool)
+	for _, parts := range names {
+		whole[strings.Join(parts, sep)] = true
+		for j := 1; j < len(parts); j++ {
+			prefixes[strings.Join(parts[:j], sep)] = true
+		}
+	}
+	out := make([][]string, len(names))
+	for i, parts := range names {
+		name := strings.Join(parts, sep)
+		clash := prefixes[name]
+		for j := 1; j < len(parts) && !clash; j++ {
+			clash = whole[strings.Join(parts[:j], sep)]
+		}
+		if clash {
+			parts = []string{name}
+		}
+		out[i] = parts
+	}
+	return out
+}
+
+// insertFlat stores value in root at the path made of parts, creating
+// nested maps as needed, and returns the canonical path. Flat formats such
+// as .env and .properties files use it to build their tree.
+func insertFlat(root map[string]any, parts []string, value any) (string, error) {
+	node := root
+	var path string
+	for i, part := range parts {
+		path = joinPath(path, part)
+		if i == len(parts)-1 {
+			if _, ok := node[part].(map[string]any); ok {
+				return path, typeMismatch("string", node[part])
+			}
+			node[part] = value
+			break
+		}
+		switch child := node[part].(type) {
+		case nil:
+			next := map[string]any{}
+			node[part] = next
+			node = next
+		case map[string]any:
+			node = child
+		default:
+			return path, typeMismatch("map[string]any", child)
+		}
+	}
+	return path, nil
+}
+
+// parseDotenvVars reads the assignments of a .env file in order.
+func parseDotenvVars(data []byte, filename string) ([]dotenvVar, error) {
+	lines := strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")
+	var vars []dotenvVar
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimLeft(lines[i], " \t")
+		if strings.TrimSpace(line) == "" || line[0] == '#' {
+			continue
+		}
+		pos := Position{File: filename, Line: i + 1, Column: len(lines[i]) - len(line) + 1}
+		if rest, ok := strings.CutPrefix(line, "export"); ok && rest != "" && (rest[0] == ' ' || rest[0] == '\t') {
+			line = strings.TrimLeft(rest, " \t")
+			pos.Column = len(lines[i]) - len(line) + 1
+		}
+
+		name, value, ok := strings.Cut(line, "=")
+		name = strings.TrimSpace(name)
+		if !ok || !validDotenvName(name) {
+			return nil, &PathError{Op: "parse", Pos: pos, Err: fmt.Errorf("invalid assignment %q", strings.TrimSpace(line))}
+		}
+
+		value = strings.TrimLeft(value, " \t")
+		if value == "" || (value[0] != '"' && value[0] != '\'') {
+			if j := strings.Index(value, " #"); j >= 0 {
+				value = value[:j]
+			}
+			if j := strings.Index(value, "\t#"); j >= 0 {
+				value = value[:j]
+			}
+			vars = append(vars, dotenvVar{name: name, value: strings.TrimSpace(value), pos: pos})
+			continue
+		}
+
+		// Quoted values continue on the next lines until the closing quote.
+		quote, value := value[0], value[1:]
+		end := closingQuote(value, quote)
+		for end < 0 && i+1 < len(lines) {
+			i++
+			value += "\n" + lines[i]
+			end = closingQuote(value, quote)
+		}
+		if end < 0 {
+			return nil, &PathError{Op: "parse", Path: name, Pos: pos, Err: errors.New("unterminated quoted value")}
+		}
+		if rest := strings.TrimSpace(value[end+1:]); rest != "" && rest[0] != '#' {
+			return nil, &PathError{Op: "parse", Path: name, Pos: pos, Err: fmt.Errorf("unexpected %q after quoted value", rest)}
+		}
+		value = value[:end]
+		if quote == '"' {
+			value = unescapeDotenv(value)
+		}
+		vars = append(vars, dotenvVar{name: name, value: value, pos: pos})
+	}
+	return vars, nil
+}
+
+// validDotenvName reports whether name can be assigned in a .env file.
+func validDotenvName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, r := range name {
+		if r != '_' && r != '.' && r != '-' && (r < 'a' || r > 'z') && (r < 'A' || r > 'Z') && (r < '0' || r > '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// closingQuote returns the index of the quote ending s, or -1. Double
+// quotes can be escaped with a backslash.
+func closingQuote(s string, quote byte) int {
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && quote == '"':
+			i++
+		case s[i] == quote:
+			return i
+		}
+	}
+	return -1
+}
+
+// dotenvEscapes maps the escape sequences of double-quoted values.
+var dotenvEscapes = strings.NewReplacer(`\n`, "\n", `\r`, "\r", `\t`, "\t", `\"`, `"`, `\\`, `\`, `\$`, "$")
+
+// unescapeDotenv expands the escape sequences of a double-quoted value.
+func unescapeDotenv(s string) string {
+	return dotenvEscapes.Replace(s)
+}
+
+// quoteDotenv returns s quoted for a .env file, if needed.
+func quoteDotenv(s string) string {
+	if !strings.ContainsAny(s, " \t\r\n#\"'\\$=") {
+		return s
+	}
+	s = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "$", `\$`, "\n", `\n`, "\r", `\r`, "\t", `\t`).Replace(s)
+	return `"` + s + `"`
+}
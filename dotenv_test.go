@@ -0,0 +1,136 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseDotenvFile(t *testing.T) {
+	cfg, err := ParseDotenvFile("testdata/app.env")
+	assert.NoError(t, err)
+
+	assert.Equal(t, "9000", cfg.UString("app.server.port"))
+	assert.Equal(t, 9000, cfg.UInt("app.server.port"))
+	assert.Equal(t, "db.local", cfg.UString("app.server.host"))
+	assert.True(t, cfg.UBool("app.debug"))
+	assert.Equal(t, "hello\n\"world\"", cfg.UString("app.greeting"))
+	assert.Equal(t, `no $expansion \n here`, cfg.UString("app.literal"))
+	assert.Equal(t, "-----BEGIN-----\nabc\n-----END-----", cfg.UString("app.cert"))
+	assert.Equal(t, "", cfg.UString("app.empty", "default"))
+
+	pos, _ := cfg.Position("app.server.host")
+	assert.Equal(t, "testdata/app.env:3:8", pos.String())
+
+	cfg, err = ParseFile("testdata/app.env")
+	assert.NoError(t, err)
+	assert.Equal(t, "9000", cfg.UString("app.server.port"))
+}
+
+func TestParseDotenvErrors(t *testing.T) {
+	_, err := ParseDotenv("A=1\nnot an assignment\n")
+	assert.EqualError(t, err, `2:1: parse: invalid assignment "not an assignment"`)
+
+	_, err = ParseDotenv("A=\"open\nB=2\n")
+	assert.EqualError(t, err, `1:1: parse "A": unterminated quoted value`)
+
+	_, err = ParseDotenv(`A="x" y`)
+	assert.EqualError(t, err, `1:1: parse "A": unexpected "y" after quoted value`)
+
+	_, err = ParseDotenv("__=1")
+	assert.EqualError(t, err, `1:1: parse: invalid name "__"`)
+}
+
+func TestParseDotenvPrefixNames(t *testing.T) {
+	for _, env := range []string{
+		"DATABASE=x\nDATABASE_URL=y\nDATABASE_POOL_SIZE=5\nAPP_PORT=1\nAPP_HOST=h\n",
+		"DATABASE_POOL_SIZE=5\nDATABASE_URL=y\nDATABASE=x\nAPP_HOST=h\nAPP_PORT=1\n",
+	} {
+		cfg, err := ParseDotenv(env)
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]any{
+			"database":           "x",
+			"database_url":       "y",
+			"database_pool_size": "5",
+			"app":                map[string]any{"port": "1", "host": "h"},
+		}, cfg.Root)
+	}
+
+	cfg, err := ParseDotenv("DATABASE=x\nDATABASE_URL=y\n")
+	assert.NoError(t, err)
+	pos, _ := cfg.Position("database_url")
+	assert.Equal(t, "2:1", pos.String())
+	out, err := RenderDotenv(cfg.Root)
+	assert.NoError(t, err)
+	assert.Equal(t, "DATABASE=x\nDATABASE_URL=y\n", out)
+}
+
+func TestRenderDotenv(t *testing.T) {
+	out, err := RenderDotenv(map[string]any{
+		"app": map[string]any{
+			"port":  8080,
+			"hosts": []any{"a", "b"},
+			"motd":  "hello \"world\"\n",
+			"none":  nil,
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "APP_HOSTS_0=a\nAPP_HOSTS_1=b\nAPP_MOTD=\"hello \\\"world\\\"\\n\"\nAPP_NONE=\nAPP_PORT=8080\n", out)
+
+	cfg, err := ParseDotenv(out)
+	assert.NoError(t, err)
+	assert.Equal(t, "hello \"world\"\n", cfg.UString("app.motd"))
+	assert.Equal(t, "b", cfg.UString("app.hosts.1"))
+
+	out, err = Render(cfg.Root, "dotenv")
+	assert.NoError(t, err)
+	assert.Contains(t, out, "APP_PORT=8080\n")
+
+	_, err = RenderDotenv("scalar")
+	assert.ErrorIs(t, err, ErrTypeMismatch)
+}
+
+func TestEnvFile(t *testing.T) {
+	cfg, err := ParseYaml(`
+server:
+  port: 8080
+  host: localhost
+  name: app
+debug: false
+`)
+	assert.NoError(t, err)
+
+	t.Setenv("APP_SERVER_HOST", "env.local")
+	cfg.EnvFile("testdata/app.env", "app").EnvPrefix("app")
+	assert.NoError(t, cfg.Error())
+
+	assert.Equal(t, 9000, cfg.UInt("server.port"))
+	assert.Equal(t, "env.local", cfg.UString("server.host"))
+	assert.Equal(t, "app", cfg.UString("server.name"))
+	assert.True(t, cfg.UBool("debug"))
+
+	sources := cfg.Explain("server.host")
+	if assert.Len(t, sources, 3) {
+		assert.Equal(t, "env APP_SERVER_HOST", sources[0].String())
+		assert.Equal(t, "env testdata/app.env:3:8", sources[1].String())
+		assert.Equal(t, "APP_SERVER_HOST", sources[1].Name)
+	}
+
+	cfg.EnvFile("testdata/missing.env", "app")
+	assert.ErrorIs(t, cfg.Error(), os.ErrNotExist)
+	cfg.EnvFile("testdata/app.env", "app")
+	assert.ErrorIs(t, cfg.Error(), os.ErrNotExist)
+
+	loaded, err := NewLoader().
+		File("testdata/default.yml").
+		EnvFile("testdata/missing.env", "", Optional()).
+		EnvFile("testdata/app.env", "").
+		Load()
+	assert.NoError(t, err)
+	assert.Equal(t, "default", loaded.UString("app.env"))
+}
@@ -0,0 +1,63 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"errors"
+	"strconv"
+)
+
+// Errors wrapped by *PathError. Use errors.Is to tell them apart:
+//
+//	_, err := cfg.Int("server.port")
+//	if errors.Is(err, config.ErrNotFound) {
+//	    // the key is missing
+//	}
+var (
+	// ErrNotFound is returned when a map key doesn't exist.
+	ErrNotFound = errors.New("key not found")
+	// ErrTypeMismatch is returned when a value doesn't have, or can't be
+	// converted to, the requested type.
+	ErrTypeMismatch = errors.New("type mismatch")
+	// ErrIndexOutOfRange is returned when a list index is beyond the end of
+	// the list.
+	ErrIndexOutOfRange = errors.New("index out of range")
+	// ErrInvalidPath is returned when a dotted path is malformed.
+	ErrInvalidPath = errors.New("invalid path")
+	// ErrOutOfRange is reported by Validate when a value is outside the
+	// bounds or the allowed values of its schema.
+	ErrOutOfRange = errors.New("value out of range")
+	// ErrPatternMismatch is reported by Validate when a string doesn't match
+	// the pattern of its schema.
+	ErrPatternMismatch = errors.New("pattern mismatch")
+	// ErrUnknownKey is reported by Validate when a map has a key its schema
+	// doesn't allow.
+	ErrUnknownKey = errors.New("unknown key")
+)
+
+// PathError records an error together with the operation and the dotted
+// path that caused it.
+type PathError struct {
+	Op   string   // operation, e.g. "get", "set", "int" or "parse"
+	Path string   // dotted path where the error occurred, may be empty
+	Err  error    // underlying error, wrapping one of the Err* values
+	Pos  Position // where the key was defined, if known
+}
+
+func (e *PathError) Error() string {
+	s := e.Op
+	if e.Path != "" {
+		s += " " + strconv.Quote(e.Path)
+	}
+	s += ": " + e.Err.Error()
+	if e.Pos.IsValid() {
+		s = e.Pos.String() + ": " + s
+	}
+	return s
+}
+
+func (e *PathError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,101 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPathError(t *testing.T) {
+	cfg, err := ParseYaml(yamlString)
+	assert.NoError(t, err)
+
+	var pathErrorTests = []struct {
+		err    error
+		target error
+		op     string
+		path   string
+	}{
+		{second(cfg.Get("map.key9")), ErrNotFound, "get", "map.key9"},
+		{second(cfg.String("map.key9")), ErrNotFound, "string", "map.key9"},
+		{second(cfg.Bool("map.key8")), ErrTypeMismatch, "bool", "map.key8"},
+		{second(cfg.Int("map.key4")), ErrTypeMismatch, "int", "map.key4"},
+		{second(cfg.Int("map.key8")), ErrTypeMismatch, "int", "map.key8"},
+		{second(cfg.Float64("list.8")), ErrTypeMismatch, "float64", "list.8"},
+		{second(cfg.List("map")), ErrTypeMismatch, "list", "map"},
+		{second(cfg.Map("list")), ErrTypeMismatch, "map", "list"},
+		{second(cfg.String("map")), ErrTypeMismatch, "string", "map"},
+		{second(cfg.String("map.key0.foo")), ErrTypeMismatch, "string", "map.key0.foo"},
+		{second(cfg.String("list.9")), ErrIndexOutOfRange, "string", "list.9"},
+		{second(cfg.String("list.foo")), ErrInvalidPath, "string", "list.foo"},
+		{second(cfg.String("map..key0")), ErrInvalidPath, "string", "map..key0"},
+		{second(cfg.Copy("config.undefined")), ErrNotFound, "get", "config.undefined"},
+		{cfg.Set("map..key0", 1), ErrInvalidPath, "set", "map..key0"},
+		{cfg.Set("list.foo", 1), ErrInvalidPath, "set", "list.foo"},
+		{cfg.Set("map.key0.foo", 1), ErrTypeMismatch, "set", "map.key0"},
+		{second(ParseJson(`{"a": 1`)), nil, "parse", ""},
+		{second(ParseYaml("a: [")), nil, "parse", ""},
+		{second(ParseToml("a = ")), nil, "parse", ""},
+	}
+
+	for _, test := range pathErrorTests {
+		var pathErr *PathError
+		if assert.ErrorAs(t, test.err, &pathErr) {
+			assert.Equal(t, test.op, pathErr.Op, test.err.Error())
+			assert.Equal(t, test.path, pathErr.Path, test.err.Error())
+		}
+		if test.target != nil {
+			assert.ErrorIs(t, test.err, test.target, test.err.Error())
+		}
+	}
+}
+
+func TestPathErrorMessages(t *testing.T) {
+	cfg, err := ParseYaml(yamlString)
+	assert.NoError(t, err)
+
+	_, err = cfg.Int("map.key9")
+	assert.EqualError(t, err, `int "map.key9": key not found`)
+
+	_, err = cfg.Bool("map.key8")
+	assert.EqualError(t, err, `11:3: bool "map.key8": type mismatch: strconv.ParseBool: parsing "value8": invalid syntax`)
+	assert.ErrorIs(t, err, strconv.ErrSyntax)
+
+	_, err = cfg.String("list.9")
+	assert.EqualError(t, err, `string "list.9": index out of range: list has only 9 items`)
+
+	_, err = cfg.Map("list")
+	assert.EqualError(t, err, `12:1: map "list": type mismatch: expected map[string]any; got []interface {}`)
+
+	_, err = normalizeValue(map[string]any{"a": []any{struct{}{}}})
+	assert.EqualError(t, err, `parse "a.0": type mismatch: unsupported type: struct {}`)
+
+	var m struct {
+		Key8 int `config:"key8"`
+	}
+	err = cfg.Unmarshal("map", &m)
+	assert.ErrorIs(t, err, ErrTypeMismatch)
+	assert.True(t, errors.Is(err, strconv.ErrSyntax))
+}
+
+func TestExtendPathError(t *testing.T) {
+	cfg, err := ParseYaml(`list: [a, b]`)
+	assert.NoError(t, err)
+	_, err = cfg.Extend(Must(ParseYaml(`list: {key0: true}`)))
+	var pathErr *PathError
+	if assert.ErrorAs(t, err, &pathErr) {
+		assert.Equal(t, "list.key0", pathErr.Path)
+	}
+	assert.ErrorIs(t, err, ErrInvalidPath)
+}
+
+// second returns the error of a two-valued call.
+func second[T any](_ T, err error) error {
+	return err
+}
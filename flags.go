@@ -0,0 +1,221 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"bytes"
+	"flag"
+	"strings"
+)
+
+// Describe sets the help text of the flags defined by Flag() and Args(),
+// keyed by dotted path. Descriptions are merged with the ones set before.
+//
+// Example:
+//
+//	cfg.Describe(map[string]string{
+//	    "server.port": "port to listen on",
+//	    "debug":       "enable debug logging",
+//	}).Flag()
+func (c *Config) Describe(descriptions map[string]string) *Config {
+	if c.safe != nil {
+		c.safe.mu.Lock()
+		defer c.safe.mu.Unlock()
+	}
+	merged := make(map[string]string, len(c.descriptions)+len(descriptions))
+	for path, desc := range c.descriptions {
+		merged[path] = desc
+	}
+	for path, desc := range descriptions {
+		merged[canonicalPath(path)] = desc
+	}
+	c.descriptions = merged
+	return c
+}
+
+// DescribeSchema sets the help text of the flags defined by Flag() and
+// Args() from the descriptions of the properties of s.
+func (c *Config) DescribeSchema(s *Schema) *Config {
+	descriptions := make(map[string]string)
+	var walk func(s *Schema, path string)
+	walk = func(s *Schema, path string) {
+		if s == nil {
+			return
+		}
+		if s.Description != "" && path != "" {
+			descriptions[path] = s.Description
+		}
+		for key, prop := range s.Properties {
+			walk(prop, joinPath(path, key))
+		}
+	}
+	walk(s, "")
+	return c.Describe(descriptions)
+}
+
+// BindFlags defines a flag in fs for every key that can be overridden, the
+// same flags Flag() and Args() define, skipping names fs already defines.
+// Flags bound to another config by an earlier call are bound to this one
+// instead. The -config flag of Loader.ConfigArgs() isn't defined.
+// Parsing is left to the caller: each flag set on the command line is
+// stored in the configuration as fs parses it, and values that can't be
+// converted are reported by the parse.
+//
+// Example:
+//
+//	fs := flag.NewFlagSet("app", flag.ExitOnError)
+//	verbose := fs.Bool("v", false, "verbose output")
+//	cfg.BindFlags(fs)
+//	fs.Parse(os.Args[1:])
+func (c *Config) BindFlags(fs *flag.FlagSet) *Config {
+	scratch := flag.NewFlagSet("", flag.ContinueOnError)
+	paths := c.defineFlags(scratch)
+	scratch.VisitAll(func(f *flag.Flag) {
+		if _, ok := paths[f.Name]; !ok {
+			return
+		}
+		existing := fs.Lookup(f.Name)
+		if existing == nil {
+			fs.Var(&boundFlag{c: c, name: f.Name, path: paths[f.Name], value: f.Value}, f.Name, f.Usage)
+			return
+		}
+		// Take over the flags bound to another config, e.g. by an
+		// earlier call to Flag().
+		if b, ok := existing.Value.(*boundFlag); ok {
+			b.c, b.path, b.value = c, paths[f.Name], f.Value
+			existing.Usage, existing.DefValue = f.Usage, f.DefValue
+		}
+	})
+	return c
+}
+
+// Positional returns the arguments left after the flags parsed by the last
+// call to Flag() or Args().
+func (c *Config) Positional() []string {
+	if c.safe != nil {
+		c.safe.mu.Lock()
+		defer c.safe.mu.Unlock()
+	}
+	return c.positional
+}
+
+// setPositional stores the arguments returned by Positional().
+func (c *Config) setPositional(args []string) {
+	if c.safe != nil {
+		c.safe.mu.Lock()
+		defer c.safe.mu.Unlock()
+	}
+	c.positional = args
+}
+
+// boundFlag is a flag defined by BindFlags. It validates values with the
+// typed flag.Value of defineFlags, then stores them in the configuration.
+type boundFlag struct {
+	c     *Config
+	name  string
+	path  string
+	value flag.Value
+}
+
+// String implements flag.Value.
+func (f *boundFlag) String() string {
+	if f.value == nil {
+		return ""
+	}
+	return f.value.String()
+}
+
+// Set implements flag.Value.
+func (f *boundFlag) Set(s string) error {
+	if err := f.value.Set(s); err != nil {
+		return err
+	}
+	return f.c.update(func(root any) (any, error) {
+		return f.c.applyFlag(root, f.name, f.path, f.value.String())
+	})
+}
+
+// IsBoolFlag lets boolean flags be set without a value.
+func (f *boundFlag) IsBoolFlag() bool {
+	b, ok := f.value.(interface{ IsBoolFlag() bool })
+	return ok && b.IsBoolFlag()
+}
+
+// Usage returns the help text of the flags defined by Flag() and Args(),
+// sorted by name, in the format of flag.PrintDefaults().
+func (c *Config) Usage() string {
+	var buf bytes.Buffer
+	fs := flag.NewFlagSet("", flag.ContinueOnError)
+	fs.SetOutput(&buf)
+	c.defineFlags(fs)
+	fs.PrintDefaults()
+	// PrintDefaults puts a space between the description and the default,
+	// even when there is no description.
+	return strings.ReplaceAll(buf.String(), "\n    \t (default ", "\n    \t(default ")
+}
+
+// defineFlags defines a flag in fs for every key that can be overridden,
+// named after its dotted path with dashes instead of dots, and returns the
+// paths of the defined flags by name. Flags are typed after the current
+// value, so bools can be set with a bare -debug and ints are validated, and
+// the current value is the default. Names fs already defines are skipped.
+// Configurations loaded by Loader.ConfigArgs() also get the -config flag,
+// which has no path and takes precedence over a "config" key.
+func (c *Config) defineFlags(fs *flag.FlagSet) map[string]string {
+	root := c.root()
+	descriptions := c.flagDescriptions()
+	paths := make(map[string]string)
+	if c.configFlag && fs.Lookup(ConfigFlag) == nil {
+		fs.Var(new(configFiles), ConfigFlag, "merge the config `files`, separated by commas")
+	}
+	for _, key := range overrideKeys(root) {
+		name := strings.Join(key, "-")
+		path := strings.Join(key, ".")
+		if fs.Lookup(name) != nil {
+			continue
+		}
+		paths[name] = path
+		usage := descriptions[canonicalPath(path)]
+		value, _ := Get(root, path)
+		switch value := value.(type) {
+		case bool:
+			fs.Bool(name, value, usage)
+		case int:
+			fs.Int(name, value, usage)
+		case float64:
+			fs.Float64(name, value, usage)
+		case []any:
+			fs.String(name, listDefault(value), usage)
+		default:
+			s, _ := toString(value)
+			fs.String(name, s, usage)
+		}
+	}
+	return paths
+}
+
+// flagDescriptions returns the descriptions set by Describe().
+func (c *Config) flagDescriptions() map[string]string {
+	if c.safe != nil {
+		c.safe.mu.Lock()
+		defer c.safe.mu.Unlock()
+	}
+	return c.descriptions
+}
+
+// listDefault returns the default value shown for a list flag: its items
+// separated by commas, or JSON if some items aren't scalars.
+func listDefault(list []any) string {
+	items := make([]string, len(list))
+	for i, item := range list {
+		s, err := toString(item)
+		if err != nil || strings.Contains(s, ",") {
+			out, _ := RenderJson(list)
+			return out
+		}
+		items[i] = s
+	}
+	return strings.Join(items, ",")
+}
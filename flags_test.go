@@ -0,0 +1,147 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const flagsYaml = `
+server:
+  port: 8080
+  host: localhost
+  ratio: 0.5
+debug: false
+ids: [1, 2]
+`
+
+func TestFlagsTyped(t *testing.T) {
+	cfg, err := ParseYaml(flagsYaml)
+	assert.NoError(t, err)
+
+	cfg.Args("app", "-debug", "-server-port", "9000", "-server-ratio=0.75")
+	assert.NoError(t, cfg.Error())
+	assert.Equal(t, true, cfg.UBool("debug"))
+	assert.Equal(t, 9000, cfg.UInt("server.port"))
+	assert.Equal(t, 0.75, cfg.UFloat64("server.ratio"))
+	assert.Equal(t, "localhost", cfg.UString("server.host"))
+}
+
+func TestUsage(t *testing.T) {
+	cfg, err := ParseYaml(flagsYaml)
+	assert.NoError(t, err)
+
+	schema, err := ParseJsonSchema(`{
+		"properties": {
+			"server": {
+				"description": "HTTP server",
+				"properties": {"port": {"type": "integer", "description": "port to listen on"}}
+			}
+		}
+	}`)
+	assert.NoError(t, err)
+	cfg.DescribeSchema(schema).Describe(map[string]string{
+		"debug":       "enable debug logging",
+		"server.host": "host to bind",
+	})
+
+	assert.Equal(t, `  -debug
+    	enable debug logging
+  -ids string
+    	(default "1,2")
+  -ids-0 int
+    	(default 1)
+  -ids-1 int
+    	(default 2)
+  -server-host string
+    	host to bind (default "localhost")
+  -server-port int
+    	port to listen on (default 8080)
+  -server-ratio float
+    	(default 0.5)
+`, cfg.Usage())
+
+	cfg.Args("app", "-h")
+	assert.ErrorIs(t, cfg.Error(), flag.ErrHelp)
+}
+
+func TestListDefault(t *testing.T) {
+	assert.Equal(t, "", listDefault(nil))
+	assert.Equal(t, "a,1,true", listDefault([]any{"a", 1, true}))
+	assert.Equal(t, `["a,b","c"]`, listDefault([]any{"a,b", "c"}))
+	assert.Equal(t, `[{"k":"v"}]`, listDefault([]any{map[string]any{"k": "v"}}))
+}
+
+func TestBindFlags(t *testing.T) {
+	cfg, err := ParseYaml(flagsYaml)
+	assert.NoError(t, err)
+
+	fs := flag.NewFlagSet("app", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	verbose := fs.Bool("debug", false, "verbose output")
+	cfg.BindFlags(fs).BindFlags(fs)
+
+	assert.NoError(t, fs.Parse([]string{"-debug", "-server-port", "9000", "-ids=3,4", "serve", "-x"}))
+	assert.True(t, *verbose)
+	assert.Equal(t, false, cfg.UBool("debug"))
+	assert.Equal(t, 9000, cfg.UInt("server.port"))
+	assert.Equal(t, []any{3, 4}, cfg.UList("ids"))
+	assert.Equal(t, []string{"serve", "-x"}, fs.Args())
+	assert.Equal(t, []Source{{Kind: "flag", Name: "server-port", Value: 9000}}, cfg.Explain("server.port")[:1])
+
+	err = fs.Parse([]string{"-server-port=http"})
+	assert.ErrorContains(t, err, `invalid value "http" for flag -server-port`)
+	err = fs.Parse([]string{"-ids=5,x"})
+	assert.ErrorContains(t, err, `invalid value "5,x" for flag -ids: item 1: type mismatch`)
+	assert.Equal(t, 9000, cfg.UInt("server.port"))
+	assert.Equal(t, []any{3, 4}, cfg.UList("ids"))
+}
+
+func TestPositional(t *testing.T) {
+	cfg, err := ParseYaml(flagsYaml)
+	assert.NoError(t, err)
+	assert.Nil(t, cfg.Positional())
+
+	cfg.Args("app", "-debug", "serve", "--port", "1")
+	assert.NoError(t, cfg.Error())
+	assert.Equal(t, []string{"serve", "--port", "1"}, cfg.Positional())
+
+	cfg.Args("app", "--", "-debug")
+	assert.Equal(t, []string{"-debug"}, cfg.Positional())
+
+	safe := NewSafe(cfg)
+	safe.Args("app", "run")
+	assert.Equal(t, []string{"run"}, safe.Positional())
+}
+
+func TestFlagsDashedKey(t *testing.T) {
+	cfg, err := ParseYaml("log-level: info\nmax-conns: 10\n")
+	assert.NoError(t, err)
+
+	cfg.Args("app", "-log-level", "debug", "-max-conns=20")
+	assert.NoError(t, cfg.Error())
+	assert.Equal(t, map[string]any{"log-level": "debug", "max-conns": 20}, cfg.Root)
+	assert.Equal(t, "  -log-level string\n    \t(default \"debug\")\n  -max-conns int\n    \t(default 20)\n", cfg.Usage())
+
+	cfg.Args("app", "-max-conns=many")
+	assert.ErrorContains(t, cfg.Error(), `invalid value "many" for flag -max-conns`)
+
+	name := filepath.Join(t.TempDir(), "app.yml")
+	assert.NoError(t, os.WriteFile(name, []byte("log-level: info\nmax-conns: 10\n"), 0o600))
+	fs := flag.NewFlagSet("app", flag.ContinueOnError)
+	fs.String("log-level", "", "")
+	fs.String("max-conns", "", "")
+	fs.String("other", "", "")
+	assert.NoError(t, fs.Parse([]string{"-log-level=warn", "-max-conns=30", "-other=x"}))
+	loaded, err := NewLoader().File(name).Flags(fs).Load()
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]any{"log-level": "warn", "max-conns": 30}, loaded.Root)
+}
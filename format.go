@@ -0,0 +1,230 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// DecodeFunc decodes a document into a tree of maps, lists and scalars,
+// e.g. by unmarshalling it into an any. The tree goes through the same
+// normalization as the built-in formats, so maps may use string or any
+// keys and numbers may be of any integer or float type. Integers that
+// don't fit an int are reported as errors.
+type DecodeFunc func(data []byte) (any, error)
+
+// EncodeFunc encodes a configuration tree into a document.
+type EncodeFunc func(cfg any) ([]byte, error)
+
+// format is a registered configuration format.
+type format struct {
+	name   string
+	parse  func(data []byte, filename string) (*Config, error)
+	render func(cfg any) (string, error)
+}
+
+var (
+	formatsMu    sync.RWMutex
+	formatByName = map[string]*format{}
+	formatByExt  = map[string]*format{}
+)
+
+func init() {
+	registerFormat(&format{name: "yaml", parse: parseYaml, render: RenderYaml}, ".yml", ".yaml")
+	registerFormat(&format{name: "json", parse: parseJson, render: RenderJson}, ".json")
+	registerFormat(&format{name: "toml", parse: parseToml, render: RenderToml}, ".toml")
+	registerFormat(&format{name: "dotenv", parse: parseDotenv, render: RenderDotenv}, ".env")
+	registerFormat(&format{name: "properties", parse: parseProperties, render: RenderProperties}, ".properties")
+	registerFormat(&format{name: "ini", parse: parseIni, render: RenderIni}, ".ini")
+}
+
+// RegisterFormat makes a configuration format available to ParseFile(),
+// ParseFS(), Render() and the Loader under the given name and file
+// extensions. Registering a name or an extension again replaces the
+// previous format. Either decode or encode may be nil for formats that
+// can only be read or written.
+//
+// The yaml, json, toml, dotenv, properties and ini formats are registered
+// by default.
+//
+// Example:
+//
+//	config.RegisterFormat("hcl", []string{".hcl"}, func(data []byte) (any, error) {
+//	    var out map[string]any
+//	    err := hclsimple.Decode("config.hcl", data, nil, &out)
+//	    return out, err
+//	}, nil)
+func RegisterFormat(name string, exts []string, decode DecodeFunc, encode EncodeFunc) {
+	f := &format{name: name}
+	if decode != nil {
+		f.parse = func(data []byte, filename string) (*Config, error) {
+			out, err := decode(data)
+			if err != nil {
+				return nil, &PathError{Op: "parse", Err: err}
+			}
+			if out, err = normalizeValue(out); err != nil {
+				return nil, err
+			}
+			return newParsed(out, filename, nil), nil
+		}
+	}
+	if encode != nil {
+		f.render = func(cfg any) (string, error) {
+			b, err := encode(cfg)
+			return string(b), err
+		}
+	}
+	registerFormat(f, exts...)
+}
+
+// registerFormat adds f to the registry.
+func registerFormat(f *format, exts ...string) {
+	formatsMu.Lock()
+	defer formatsMu.Unlock()
+	formatByName[f.name] = f
+	for _, ext := range exts {
+		if !strings.HasPrefix(ext, ".") {
+			ext = "." + ext
+		}
+		formatByExt[strings.ToLower(ext)] = f
+	}
+}
+
+// unregisterFormat removes the format registered under name, along with
+// its extensions.
+func unregisterFormat(name string) {
+	formatsMu.Lock()
+	defer formatsMu.Unlock()
+	f, ok := formatByName[name]
+	if !ok {
+		return
+	}
+	delete(formatByName, name)
+	for ext, g := range formatByExt {
+		if g == f {
+			delete(formatByExt, ext)
+		}
+	}
+}
+
+// lookupFormat returns the format registered under name.
+func lookupFormat(name string) (*format, bool) {
+	formatsMu.RLock()
+	defer formatsMu.RUnlock()
+	f, ok := formatByName[name]
+	return f, ok
+}
+
+// lookupExt returns the format registered for the extension of filename.
+func lookupExt(filename string) (*format, bool) {
+	formatsMu.RLock()
+	defer formatsMu.RUnlock()
+	f, ok := formatByExt[strings.ToLower(filepath.Ext(filename))]
+	return f, ok
+}
+
+// ParseFile reads a configuration from the given filename.
+//
+// The format is chosen by the file extension among the registered
+// formats. Files with an unknown extension are sniffed: content starting
+// with { is parsed as JSON, or as YAML if it isn't valid JSON, then JSON
+// lists and TOML are tried, and YAML is used as a last resort.
+//
+// Example:
+//
+//	cfg, err := config.ParseFile(os.Getenv("APP_CONFIG"))
+func ParseFile(filename string) (*Config, error) {
+	filename = filepath.Clean(filename)
+	if _, ok := lookupExt(filename); ok {
+		return parseFileByExt(filename)
+	}
+
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := sniff(data, filename)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	return cfg, nil
+}
+
+// sniff parses data in the format its content looks like.
+func sniff(data []byte, filename string) (*Config, error) {
+	trimmed := bytes.TrimSpace(data)
+	// A leading { is either a JSON object or a YAML flow mapping. The
+	// JSON error is more telling if neither parses.
+	if len(trimmed) > 0 && trimmed[0] == '{' {
+		cfg, err := parseJson(data, filename)
+		if err == nil {
+			return cfg, nil
+		}
+		if cfg, yamlErr := parseYaml(data, filename); yamlErr == nil {
+			return cfg, nil
+		}
+		return nil, err
+	}
+	// A leading [ is either a JSON list or a TOML table header.
+	if len(trimmed) > 0 && trimmed[0] == '[' {
+		if cfg, err := parseJson(data, filename); err == nil {
+			return cfg, nil
+		}
+	}
+	if cfg, err := parseToml(data, filename); err == nil {
+		return cfg, nil
+	}
+	return parseYaml(data, filename)
+}
+
+// Render renders a configuration in the named format, e.g. "yaml", "json",
+// "toml", "dotenv", "properties", "ini" or any format added with
+// RegisterFormat().
+//
+// Example:
+//
+//	out, err := config.Render(cfg.Root, "json")
+func Render(cfg any, name string) (string, error) {
+	f, ok := lookupFormat(name)
+	if !ok {
+		return "", fmt.Errorf("unknown format %q", name)
+	}
+	if f.render == nil {
+		return "", fmt.Errorf("format %q cannot be rendered", name)
+	}
+	return f.render(cfg)
+}
+
+// parseFileByExt parses filename in the format given by its extension.
+func parseFileByExt(filename string) (*Config, error) {
+	return parseByExt(os.ReadFile, filepath.Clean(filename))
+}
+
+// parseByExt reads filename with readFile and parses it in the format given
+// by its extension. Parse errors are prefixed with the file name.
+func parseByExt(readFile func(string) ([]byte, error), filename string) (*Config, error) {
+	f, ok := lookupExt(filename)
+	if !ok {
+		return nil, fmt.Errorf("%s: unsupported file format %q", filename, filepath.Ext(filename))
+	}
+	if f.parse == nil {
+		return nil, fmt.Errorf("%s: format %q cannot be parsed", filename, f.name)
+	}
+
+	data, err := readFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := f.parse(data, filename)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	return cfg, nil
+}
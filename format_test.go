@@ -0,0 +1,174 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// decodeKeyValue decodes "key=value" lines into a flat map.
+func decodeKeyValue(data []byte) (any, error) {
+	out := map[any]any{}
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, errors.New("missing =")
+		}
+		out[key] = value
+	}
+	return out, nil
+}
+
+// encodeKeyValue encodes a flat map into sorted "key=value" lines.
+func encodeKeyValue(cfg any) ([]byte, error) {
+	m, ok := cfg.(map[string]any)
+	if !ok {
+		return nil, typeMismatch("map[string]any", cfg)
+	}
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var buf bytes.Buffer
+	for _, k := range keys {
+		fmt.Fprintf(&buf, "%s=%v\n", k, m[k])
+	}
+	return buf.Bytes(), nil
+}
+
+func TestRegisterFormat(t *testing.T) {
+	RegisterFormat("keyvalue", []string{"kv", ".KVS"}, decodeKeyValue, encodeKeyValue)
+	RegisterFormat("keyvalue-read", []string{".kvr"}, decodeKeyValue, nil)
+	t.Cleanup(func() {
+		unregisterFormat("keyvalue")
+		unregisterFormat("keyvalue-read")
+	})
+
+	dir := t.TempDir()
+	name := filepath.Join(dir, "app.kv")
+	assert.NoError(t, os.WriteFile(name, []byte("host=localhost\nport=8080\n"), 0o600))
+
+	cfg, err := ParseFile(name)
+	assert.NoError(t, err)
+	assert.Equal(t, "localhost", cfg.UString("host"))
+	assert.Equal(t, 8080, cfg.UInt("port"))
+	sources := cfg.Explain("port")
+	if assert.Len(t, sources, 1) {
+		assert.Equal(t, name, sources[0].Name)
+	}
+
+	out, err := Render(cfg.Root, "keyvalue")
+	assert.NoError(t, err)
+	assert.Equal(t, "host=localhost\nport=8080\n", out)
+	_, err = Render(cfg.Root, "keyvalue-read")
+	assert.EqualError(t, err, `format "keyvalue-read" cannot be rendered`)
+
+	fsys := fstest.MapFS{
+		"app.kvs": {Data: []byte("debug=true")},
+		"bad.kvs": {Data: []byte("debug")},
+	}
+	cfg, err = ParseFS(fsys, "app.kvs")
+	assert.NoError(t, err)
+	assert.True(t, cfg.UBool("debug"))
+	_, err = ParseFS(fsys, "bad.kvs")
+	assert.EqualError(t, err, "bad.kvs: parse: missing =")
+}
+
+func TestRegisterFormatNumbers(t *testing.T) {
+	RegisterFormat("typed", []string{".typed"}, func([]byte) (any, error) {
+		return map[string]any{
+			"i64":  int64(8080),
+			"u8":   uint8(7),
+			"f32":  float32(0.1),
+			"list": []any{int32(1), uint16(2)},
+		}, nil
+	}, nil)
+	RegisterFormat("overflow", []string{".overflow"}, func([]byte) (any, error) {
+		return map[string]any{"big": uint64(math.MaxUint64)}, nil
+	}, nil)
+	t.Cleanup(func() {
+		unregisterFormat("typed")
+		unregisterFormat("overflow")
+	})
+
+	fsys := fstest.MapFS{
+		"app.typed":    {Data: []byte{}},
+		"app.overflow": {Data: []byte{}},
+	}
+	cfg, err := ParseFS(fsys, "app.typed")
+	assert.NoError(t, err)
+	assert.Equal(t, 8080, cfg.UInt("i64"))
+	assert.Equal(t, 7, cfg.UInt("u8"))
+	assert.Equal(t, 0.1, cfg.UFloat64("f32"))
+	assert.Equal(t, []any{1, 2}, cfg.UList("list"))
+
+	_, err = ParseFS(fsys, "app.overflow")
+	assert.ErrorIs(t, err, ErrTypeMismatch)
+	assert.ErrorContains(t, err, "18446744073709551615 overflows int")
+}
+
+func TestParseFile(t *testing.T) {
+	cfg, err := ParseFile("testdata/default.yml")
+	assert.NoError(t, err)
+	assert.Equal(t, "default", cfg.UString("app.env"))
+	pos, _ := cfg.Position("app.env")
+	assert.Equal(t, "testdata/default.yml:2:3", pos.String())
+
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"json":  `{"app": {"env": "json"}}`,
+		"toml":  "[app]\nenv = \"toml\"\n",
+		"yaml":  "app:\n  env: yaml\n",
+		"flow":  "{app: {env: flow}}",
+		"empty": "",
+	} {
+		filename := filepath.Join(dir, name)
+		assert.NoError(t, os.WriteFile(filename, []byte(content), 0o600))
+		cfg, err = ParseFile(filename)
+		if assert.NoError(t, err, name) && name != "empty" {
+			assert.Equal(t, name, cfg.UString("app.env"), name)
+		}
+	}
+
+	filename := filepath.Join(dir, "broken")
+	assert.NoError(t, os.WriteFile(filename, []byte("{app"), 0o600))
+	_, err = ParseFile(filename)
+	assert.ErrorContains(t, err, filename+": parse:")
+	assert.ErrorContains(t, err, "invalid character")
+
+	_, err = ParseFile(filepath.Join(dir, "missing"))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestRender(t *testing.T) {
+	root := map[string]any{"app": map[string]any{"port": 8080}}
+
+	out, err := Render(root, "json")
+	assert.NoError(t, err)
+	assert.Equal(t, `{"app":{"port":8080}}`, out)
+
+	out, err = Render(root, "yaml")
+	assert.NoError(t, err)
+	assert.Equal(t, "app:\n  port: 8080\n", out)
+
+	out, err = Render(root, "toml")
+	assert.NoError(t, err)
+	assert.Contains(t, out, "port = 8080")
+
+	_, err = Render(root, "xml")
+	assert.EqualError(t, err, `unknown format "xml"`)
+}
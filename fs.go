@@ -0,0 +1,58 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"io/fs"
+	"path"
+)
+
+// ParseFS reads the named file from fsys and parses it in the format given
+// by its extension, among the formats known to ParseFile().
+//
+// This allows loading configurations embedded in the binary with
+// //go:embed, or from a testing/fstest.MapFS in tests.
+//
+// Example:
+//
+//	//go:embed defaults.yml
+//	var defaults embed.FS
+//
+//	cfg, err := config.ParseFS(defaults, "defaults.yml")
+func ParseFS(fsys fs.FS, name string) (*Config, error) {
+	return parseByExt(readFS(fsys), name)
+}
+
+// ParseFSDir is like ParseDir() but reads the directory from fsys.
+func ParseFSDir(fsys fs.FS, dir string, opts ...DirOption) (*Config, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, entry := range entries {
+		if !entry.IsDir() && isConfigFile(entry.Name()) {
+			files = append(files, path.Join(dir, entry.Name()))
+		}
+	}
+	return parseFiles(readFS(fsys), files, opts)
+}
+
+// ParseFSGlob is like ParseGlob() but matches the files in fsys, using the
+// pattern syntax of path.Match.
+func ParseFSGlob(fsys fs.FS, pattern string, opts ...DirOption) (*Config, error) {
+	files, err := fs.Glob(fsys, pattern)
+	if err != nil {
+		return nil, err
+	}
+	return parseFiles(readFS(fsys), files, opts)
+}
+
+// readFS returns a function reading files from fsys.
+func readFS(fsys fs.FS) func(string) ([]byte, error) {
+	return func(name string) ([]byte, error) {
+		return fs.ReadFile(fsys, name)
+	}
+}
@@ -0,0 +1,72 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"embed"
+	"io/fs"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+//go:embed testdata/conf.d testdata/default.toml
+var testdataFS embed.FS
+
+func TestParseFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"app.yml":  {Data: []byte("server:\n  port: 8080\n")},
+		"app.json": {Data: []byte(`{"server": {"port": 9000}}`)},
+		"bad.json": {Data: []byte(`{"server": `)},
+		"app.txt":  {Data: []byte("port=1")},
+	}
+
+	cfg, err := ParseFS(fsys, "app.yml")
+	assert.NoError(t, err)
+	assert.Equal(t, 8080, cfg.UInt("server.port"))
+	pos, _ := cfg.Position("server.port")
+	assert.Equal(t, "app.yml:2:3", pos.String())
+
+	cfg, err = ParseFS(fsys, "app.json")
+	assert.NoError(t, err)
+	assert.Equal(t, 9000, cfg.UInt("server.port"))
+
+	cfg, err = ParseFS(testdataFS, "testdata/default.toml")
+	assert.NoError(t, err)
+	assert.NotEmpty(t, cfg.Root)
+
+	_, err = ParseFS(fsys, "bad.json")
+	assert.ErrorContains(t, err, "bad.json: parse:")
+	_, err = ParseFS(fsys, "app.txt")
+	assert.ErrorContains(t, err, `unsupported file format ".txt"`)
+	_, err = ParseFS(fsys, "missing.yml")
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+}
+
+func TestParseFSDir(t *testing.T) {
+	cfg, err := ParseFSDir(testdataFS, "testdata/conf.d")
+	assert.NoError(t, err)
+	assert.Equal(t, "localhost", cfg.UString("server.host"))
+	assert.Equal(t, 9000, cfg.UInt("server.port"))
+	assert.True(t, cfg.UBool("server.debug"))
+
+	cfg, err = ParseFSDir(testdataFS, "testdata/conf.d", NestByFile())
+	assert.NoError(t, err)
+	assert.Equal(t, 8080, cfg.UInt("00-base.server.port"))
+
+	_, err = ParseFSDir(testdataFS, "testdata/missing")
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+}
+
+func TestParseFSGlob(t *testing.T) {
+	cfg, err := ParseFSGlob(testdataFS, "testdata/conf.d/*.y*ml")
+	assert.NoError(t, err)
+	assert.Equal(t, 8080, cfg.UInt("server.port"))
+	assert.True(t, cfg.UBool("server.debug"))
+
+	_, err = ParseFSGlob(testdataFS, "testdata/[")
+	assert.Error(t, err)
+}
@@ -0,0 +1,170 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+)
+
+// GNUArgs parses command line arguments GNU style, based on existing config
+// keys. It is an alternative to Args() for programs whose users expect the
+// conventions of GNU tools:
+//
+//   - Options start with two dashes and name a key by its dotted path or
+//     with dashes instead of dots: --server.port 9000, --server-port=9000.
+//   - Bool keys are set with a bare --debug and cleared with --no-debug.
+//   - Repeating a list key builds the list: --ids a --ids b.
+//   - --set path=value overrides any path, even one that doesn't exist yet.
+//     Like EnvScan(), it can't set an item more than 1024 items past the
+//     end of a list.
+//   - Options and positional arguments can be mixed, and -- ends the
+//     options. The positional arguments are returned by Positional().
+//
+// Values are converted to the type of the value they override, like Args()
+// does. Parse errors and values that can't be converted are reported by
+// Error(); -h and --help report flag.ErrHelp.
+//
+// Example:
+//
+//	cfg.GNUArgs(os.Args...)
+func (c *Config) GNUArgs(args ...string) *Config {
+	if len(args) <= 1 {
+		return c
+	}
+	c.setError(c.gnuArgs(args[1:]))
+	return c
+}
+
+// gnuOption is an option read by GNUArgs().
+type gnuOption struct {
+	name  string // as written, without dashes
+	path  string
+	value string
+}
+
+// gnuArgs implements GNUArgs.
+func (c *Config) gnuArgs(args []string) error {
+	opts, positional, parseErr := parseGNUArgs(c.root(), args)
+	c.setPositional(positional)
+
+	var errs []error
+	_ = c.update(func(root any) (any, error) {
+		lists := make(map[string][]any)
+		for _, o := range opts {
+			current, _ := Get(root, o.path)
+			val, err := coerce(current, o.value)
+			if items, ok := val.([]any); ok && err == nil {
+				// Repeated list options add to the list they built.
+				if prev, seen := lists[o.path]; seen {
+					items = append(prev[:len(prev):len(prev)], items...)
+					val = items
+				}
+				lists[o.path] = items
+			}
+			if err == nil {
+				err = checkListGrowth(root, strings.Split(o.path, "."))
+			}
+			if err == nil {
+				var r any
+				if r, err = setPath(root, o.path, val); err == nil {
+					root = r
+				}
+			}
+			if err != nil {
+				errs = append(errs, fmt.Errorf("flag --%s: %w", o.name, err))
+				continue
+			}
+			c.record(o.path, Source{Kind: "flag", Name: o.name, Value: val})
+		}
+		return root, nil
+	})
+	return errors.Join(parseErr, errors.Join(errs...))
+}
+
+// parseGNUArgs splits args into the options overriding keys of root and the
+// positional arguments. Parsing stops at the first invalid option, and the
+// options read before it are returned along with the error.
+func parseGNUArgs(root any, args []string) ([]gnuOption, []string, error) {
+	paths := make(map[string]string)
+	for _, key := range overrideKeys(root) {
+		path := strings.Join(key, ".")
+		paths[path] = path
+		paths[strings.Join(key, "-")] = path
+	}
+	isBool := func(path string) bool {
+		v, _ := Get(root, path)
+		_, ok := v.(bool)
+		return ok
+	}
+
+	var opts []gnuOption
+	var positional []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--":
+			return opts, append(positional, args[i+1:]...), nil
+		case arg == "-h" || arg == "--help":
+			return opts, positional, flag.ErrHelp
+		case arg == "-" || !strings.HasPrefix(arg, "-"):
+			positional = append(positional, arg)
+			continue
+		case !strings.HasPrefix(arg, "--"):
+			return opts, positional, fmt.Errorf("unknown option %s", arg)
+		}
+
+		name, value, hasValue := strings.Cut(arg[2:], "=")
+		// next returns the value of an option that requires one.
+		next := func() (string, error) {
+			if hasValue {
+				return value, nil
+			}
+			if i+1 == len(args) {
+				return "", fmt.Errorf("option --%s needs a value", name)
+			}
+			i++
+			return args[i], nil
+		}
+
+		if name == "set" {
+			assignment, err := next()
+			if err != nil {
+				return opts, positional, err
+			}
+			path, v, ok := strings.Cut(assignment, "=")
+			if !ok || path == "" {
+				return opts, positional, fmt.Errorf("option --set: %q is not path=value", assignment)
+			}
+			opts = append(opts, gnuOption{name: name, path: path, value: v})
+			continue
+		}
+
+		if path, ok := paths[name]; ok {
+			if isBool(path) && !hasValue {
+				value = "true"
+			} else {
+				var err error
+				if value, err = next(); err != nil {
+					return opts, positional, err
+				}
+			}
+			opts = append(opts, gnuOption{name: name, path: path, value: value})
+			continue
+		}
+
+		if path, ok := paths[strings.TrimPrefix(name, "no-")]; ok && strings.HasPrefix(name, "no-") && isBool(path) {
+			if hasValue {
+				return opts, positional, fmt.Errorf("option --%s doesn't take a value", name)
+			}
+			opts = append(opts, gnuOption{name: name, path: path, value: "false"})
+			continue
+		}
+		return opts, positional, fmt.Errorf("unknown option --%s", name)
+	}
+	return opts, positional, nil
+}
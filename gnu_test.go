@@ -0,0 +1,72 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"flag"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGNUArgs(t *testing.T) {
+	cfg, err := ParseYaml(flagsYaml)
+	assert.NoError(t, err)
+
+	cfg.GNUArgs("app", "serve", "--server.port", "9000", "--debug", "--ids", "3", "--ids=4,5",
+		"--server-host=0.0.0.0", "--set", "log.level=info", "--set=server.ratio=0.25", "--", "--debug")
+	assert.NoError(t, cfg.Error())
+	assert.Equal(t, 9000, cfg.UInt("server.port"))
+	assert.Equal(t, true, cfg.UBool("debug"))
+	assert.Equal(t, []any{3, 4, 5}, cfg.UList("ids"))
+	assert.Equal(t, "0.0.0.0", cfg.UString("server.host"))
+	assert.Equal(t, "info", cfg.UString("log.level"))
+	assert.Equal(t, 0.25, cfg.UFloat64("server.ratio"))
+	assert.Equal(t, []string{"serve", "--debug"}, cfg.Positional())
+	assert.Equal(t, Source{Kind: "flag", Name: "server.port", Value: 9000}, cfg.Explain("server.port")[0])
+	assert.Equal(t, Source{Kind: "flag", Name: "set", Value: "info"}, cfg.Explain("log.level")[0])
+
+	cfg.GNUArgs("app", "--no-debug", "--ids", "6")
+	assert.NoError(t, cfg.Error())
+	assert.Equal(t, false, cfg.UBool("debug"))
+	assert.Equal(t, []any{6}, cfg.UList("ids"))
+
+	cfg.GNUArgs("app", "--debug=true", "--server-port", "http", "--ids", "x")
+	assert.ErrorIs(t, cfg.Error(), ErrTypeMismatch)
+	assert.ErrorContains(t, cfg.Error(), "flag --server-port: type mismatch")
+	assert.ErrorContains(t, cfg.Error(), "flag --ids: item 0: type mismatch")
+	assert.Equal(t, true, cfg.UBool("debug"))
+	assert.Equal(t, 9000, cfg.UInt("server.port"))
+
+	cfg.GNUArgs("app", "--set", "items.3=x", "--set", "huge.99999999999=x")
+	assert.EqualError(t, cfg.Error(), `flag --set: set "huge.99999999999": invalid path: index 99999999999 is too far past the end of a list of 0 items`)
+	assert.Equal(t, []any{nil, nil, nil, "x"}, cfg.UList("items"))
+}
+
+func TestGNUArgsErrors(t *testing.T) {
+	cfg, err := ParseYaml(flagsYaml)
+	assert.NoError(t, err)
+
+	cases := []struct {
+		args []string
+		err  string
+	}{
+		{[]string{"--undefined"}, "unknown option --undefined"},
+		{[]string{"-debug"}, "unknown option -debug"},
+		{[]string{"--no-ids"}, "unknown option --no-ids"},
+		{[]string{"--no-debug=true"}, "option --no-debug doesn't take a value"},
+		{[]string{"--server-port"}, "option --server-port needs a value"},
+		{[]string{"--set", "debug"}, `option --set: "debug" is not path=value`},
+	}
+	for _, tc := range cases {
+		cfg.GNUArgs(append([]string{"app"}, tc.args...)...)
+		assert.EqualError(t, cfg.Error(), tc.err, tc.args)
+	}
+
+	cfg.GNUArgs("app", "--server-port", "9000", "--help", "--debug")
+	assert.ErrorIs(t, cfg.Error(), flag.ErrHelp)
+	assert.Equal(t, 9000, cfg.UInt("server.port"))
+	assert.Equal(t, false, cfg.UBool("debug"))
+}
@@ -0,0 +1,181 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ParseIni parses an INI file from the given string.
+//
+// Each [section] becomes a top-level map holding the key = value (or
+// key: value) pairs that follow it; pairs before the first section are
+// top-level keys. Lines starting with ; or # are comments, and so is the
+// rest of an unquoted value after " ;" or " #". Values may be wrapped in
+// single or double quotes to keep surrounding spaces. All values are
+// strings.
+func ParseIni(cfg string) (*Config, error) {
+	return parseIni([]byte(cfg), "")
+}
+
+// ParseIniFile reads an INI file from the given filename. See ParseIni() for
+// the syntax.
+func ParseIniFile(filename string) (*Config, error) {
+	cfg, err := os.ReadFile(filepath.Clean(filename))
+	if err != nil {
+		return nil, err
+	}
+	return parseIni(cfg, filename)
+}
+
+// RenderIni renders a configuration as an INI file, the reverse of
+// ParseIni(). The configuration must be a map whose values are either
+// scalars or maps of scalars, which are written as sections. Keys are
+// sorted. Values holding both single and double quotes can't be written,
+// since INI values have no escapes.
+func RenderIni(cfg any) (string, error) {
+	root, ok := cfg.(map[string]any)
+	if !ok {
+		return "", typeMismatch("map[string]any", cfg)
+	}
+
+	var b strings.Builder
+	var sections []string
+	for _, key := range sortedKeys(root) {
+		if _, ok := root[key].(map[string]any); ok {
+			sections = append(sections, key)
+			continue
+		}
+		if err := writeIniPair(&b, "", key, root[key]); err != nil {
+			return "", err
+		}
+	}
+	for _, name := range sections {
+		if b.Len() > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString("[" + name + "]\n")
+		section := root[name].(map[string]any)
+		for _, key := range sortedKeys(section) {
+			if err := writeIniPair(&b, name, key, section[key]); err != nil {
+				return "", err
+			}
+		}
+	}
+	return b.String(), nil
+}
+
+// writeIniPair writes a key = value line for a value of the given section.
+func writeIniPair(b *strings.Builder, section, key string, value any) error {
+	var s string
+	switch value.(type) {
+	case nil:
+	case map[string]any, []any:
+		return &PathError{Op: "render", Path: joinPath(joinPath("", section), key), Err: typeMismatch("scalar", value)}
+	default:
+		var err error
+		if s, err = toString(value); err != nil {
+			return &PathError{Op: "render", Path: joinPath(joinPath("", section), key), Err: err}
+		}
+	}
+	if s != strings.TrimSpace(s) || strings.ContainsAny(s, ";#\"'") {
+		quote := `"`
+		if strings.Contains(s, quote) {
+			quote = "'"
+		}
+		if strings.Contains(s, quote) {
+			// INI values have no escapes: a value holding both quotes
+			// can't be read back.
+			return &PathError{Op: "render", Path: joinPath(joinPath("", section), key), Err: fmt.Errorf("can't quote %q", s)}
+		}
+		s = quote + s + quote
+	}
+	if s == "" {
+		fmt.Fprintf(b, "%s =\n", key)
+	} else {
+		fmt.Fprintf(b, "%s = %s\n", key, s)
+	}
+	return nil
+}
+
+// sortedKeys returns the keys of m in order.
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// parseIni performs the real INI parsing.
+func parseIni(cfg []byte, filename string) (*Config, error) {
+	root := map[string]any{}
+	pos := make(map[string]Position)
+	section, sectionPath := root, ""
+	for i, raw := range strings.Split(strings.ReplaceAll(string(cfg), "\r\n", "\n"), "\n") {
+		line := strings.TrimSpace(raw)
+		if line == "" || line[0] == ';' || line[0] == '#' {
+			continue
+		}
+		p := Position{File: filename, Line: i + 1, Column: strings.Index(raw, line) + 1}
+
+		if line[0] == '[' {
+			end := strings.IndexByte(line, ']')
+			if end < 0 {
+				return nil, &PathError{Op: "parse", Pos: p, Err: fmt.Errorf("unterminated section header %q", line)}
+			}
+			name := strings.TrimSpace(line[1:end])
+			if rest := strings.TrimSpace(line[end+1:]); name == "" || (rest != "" && rest[0] != ';' && rest[0] != '#') {
+				return nil, &PathError{Op: "parse", Pos: p, Err: fmt.Errorf("invalid section header %q", line)}
+			}
+			sectionPath = joinPath("", name)
+			switch existing := root[name].(type) {
+			case nil:
+				section = map[string]any{}
+				root[name] = section
+				pos[sectionPath] = p
+			case map[string]any:
+				section = existing
+			default:
+				return nil, &PathError{Op: "parse", Path: sectionPath, Pos: p, Err: typeMismatch("map[string]any", existing)}
+			}
+			continue
+		}
+
+		sep := strings.IndexAny(line, "=:")
+		if sep <= 0 {
+			return nil, &PathError{Op: "parse", Pos: p, Err: fmt.Errorf("invalid assignment %q", line)}
+		}
+		key := strings.TrimSpace(line[:sep])
+		path := joinPath(sectionPath, key)
+		if existing, ok := section[key].(map[string]any); ok {
+			return nil, &PathError{Op: "parse", Path: path, Pos: p, Err: typeMismatch("string", existing)}
+		}
+		section[key] = iniValue(strings.TrimSpace(line[sep+1:]))
+		pos[path] = p
+	}
+	return newParsed(root, filename, pos), nil
+}
+
+// iniValue returns the value of a raw INI value, removing quotes and
+// comments.
+func iniValue(s string) string {
+	if len(s) > 1 && (s[0] == '"' || s[0] == '\'') {
+		if end := strings.IndexByte(s[1:], s[0]); end >= 0 {
+			return s[1 : end+1]
+		}
+	}
+	for _, comment := range []string{" ;", " #", "\t;", "\t#"} {
+		if i := strings.Index(s, comment); i >= 0 {
+			s = s[:i]
+		}
+	}
+	return strings.TrimSpace(s)
+}
@@ -0,0 +1,110 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseIniFile(t *testing.T) {
+	cfg, err := ParseIniFile("testdata/app.ini")
+	assert.NoError(t, err)
+
+	assert.Equal(t, "app", cfg.UString("name"))
+	assert.Equal(t, "localhost", cfg.UString("server.host"))
+	assert.Equal(t, 8080, cfg.UInt("server.port"))
+	assert.Equal(t, "  padded  ", cfg.UString("server.motd"))
+	assert.Equal(t, "postgres://db#1", cfg.UString("database.url"))
+
+	pos, _ := cfg.Position("server.port")
+	assert.Equal(t, "testdata/app.ini:6:1", pos.String())
+	pos, _ = cfg.Position("database")
+	assert.Equal(t, 9, pos.Line)
+
+	cfg, err = ParseFile("testdata/app.ini")
+	assert.NoError(t, err)
+	assert.Equal(t, "app", cfg.UString("name"))
+}
+
+func TestParseIniErrors(t *testing.T) {
+	_, err := ParseIni("[server\nport=1")
+	assert.EqualError(t, err, `1:1: parse: unterminated section header "[server"`)
+
+	_, err = ParseIni("[] ")
+	assert.EqualError(t, err, `1:1: parse: invalid section header "[]"`)
+
+	_, err = ParseIni("[a]\n  port")
+	assert.EqualError(t, err, `2:3: parse: invalid assignment "port"`)
+
+	_, err = ParseIni("server = x\n[server]\n")
+	assert.ErrorIs(t, err, ErrTypeMismatch)
+
+	_, err = ParseIni("[server]\n[main]\nserver = x\n")
+	assert.NoError(t, err)
+
+	_, err = ParseIni("[server]\nport=1\n[]\n")
+	assert.Error(t, err)
+}
+
+func TestRenderIni(t *testing.T) {
+	root := map[string]any{
+		"name":     "app",
+		"debug":    true,
+		"server":   map[string]any{"port": 8080, "motd": " hi ", "quote": `say "hi"`},
+		"database": map[string]any{"url": "db;1", "none": nil},
+	}
+	out, err := RenderIni(root)
+	assert.NoError(t, err)
+	assert.Equal(t, `debug = true
+name = app
+
+[database]
+none =
+url = "db;1"
+
+[server]
+motd = " hi "
+port = 8080
+quote = 'say "hi"'
+`, out)
+
+	cfg, err := ParseIni(out)
+	assert.NoError(t, err)
+	assert.Equal(t, " hi ", cfg.UString("server.motd"))
+	assert.Equal(t, `say "hi"`, cfg.UString("server.quote"))
+	assert.Equal(t, "db;1", cfg.UString("database.url"))
+
+	out, err = Render(cfg.Root, "ini")
+	assert.NoError(t, err)
+	assert.Contains(t, out, "[server]\n")
+
+	_, err = RenderIni(map[string]any{"a": map[string]any{"b": []any{1}}})
+	assert.EqualError(t, err, `render "a.b": type mismatch: expected scalar; got []interface {}`)
+	_, err = RenderIni([]any{1})
+	assert.ErrorIs(t, err, ErrTypeMismatch)
+	_, err = RenderIni(map[string]any{"s": map[string]any{"q": `it's "hi"`}})
+	assert.EqualError(t, err, `render "s.q": can't quote "it's \"hi\""`)
+}
+
+func TestRenderIniRoundTrip(t *testing.T) {
+	root := map[string]any{
+		"top": map[string]any{
+			"double": `say "hi"`,
+			"single": "it's",
+			"both":   `'quoted'`,
+			"spaces": "  padded  ",
+			"marks":  "a ; b # c",
+			"plain":  "value",
+			"empty":  "",
+		},
+	}
+	out, err := RenderIni(root)
+	assert.NoError(t, err)
+	cfg, err := ParseIni(out)
+	assert.NoError(t, err)
+	assert.Equal(t, root, cfg.Root)
+}
@@ -0,0 +1,317 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"slices"
+	"strings"
+)
+
+// Loader builds a configuration from layers applied in the order they are
+// declared, each one overriding the previous ones.
+//
+// Example:
+//
+//	cfg, err := config.NewLoader().
+//	    File("base.yml").
+//	    File("prod.yml", config.Optional()).
+//	    EnvPrefix("APP").
+//	    Args(os.Args...).
+//	    Load()
+type Loader struct {
+	steps []func(*Config) (*Config, error)
+}
+
+// NewLoader returns an empty loader.
+func NewLoader() *Loader {
+	return &Loader{}
+}
+
+// FileOption configures a file layer of a Loader.
+type FileOption func(*fileOptions)
+
+type fileOptions struct {
+	optional bool
+}
+
+// Optional makes a missing file a no-op instead of an error.
+func Optional() FileOption {
+	return func(o *fileOptions) {
+		o.optional = true
+	}
+}
+
+// File merges the given file with Extend() semantics. The format is chosen
+// by the file extension among the formats known to ParseFile().
+func (l *Loader) File(filename string, opts ...FileOption) *Loader {
+	var o fileOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return l.step(func(cfg *Config) (*Config, error) {
+		file, err := parseFileByExt(filename)
+		if err != nil {
+			if o.optional && errors.Is(err, fs.ErrNotExist) {
+				return cfg, nil
+			}
+			return nil, err
+		}
+		return cfg.Extend(file)
+	})
+}
+
+// Dir merges the files in dir, like ParseDir().
+func (l *Loader) Dir(dir string, opts ...DirOption) *Loader {
+	return l.step(func(cfg *Config) (*Config, error) {
+		files, err := ParseDir(dir, opts...)
+		if err != nil {
+			return nil, err
+		}
+		return cfg.Extend(files)
+	})
+}
+
+// Profile merges the configuration of a profile, like LoadProfile().
+func (l *Loader) Profile(dir, profile string) *Loader {
+	return l.step(func(cfg *Config) (*Config, error) {
+		file, err := LoadProfile(dir, profile)
+		if err != nil {
+			return nil, err
+		}
+		return cfg.Extend(file)
+	})
+}
+
+// Env overrides existing keys from environment variables, like Env().
+func (l *Loader) Env() *Loader {
+	return l.EnvPrefix("")
+}
+
+// EnvPrefix overrides existing keys from environment variables starting
+// with prefix, like EnvPrefix().
+func (l *Loader) EnvPrefix(prefix string) *Loader {
+	return l.step(func(cfg *Config) (*Config, error) {
+		return cfg, cfg.applyEnv(prefix, lookupEnv)
+	})
+}
+
+// EnvScan sets keys from all environment variables starting with prefix,
+// creating missing ones, like EnvScan().
+func (l *Loader) EnvScan(prefix, separator string) *Loader {
+	return l.step(func(cfg *Config) (*Config, error) {
+		return cfg, cfg.envScan(prefix, separator)
+	})
+}
+
+// EnvFile overrides existing keys from the variables of a .env file starting
+// with prefix, like EnvFile(). With the Optional() option a missing file is
+// a no-op.
+func (l *Loader) EnvFile(filename, prefix string, opts ...FileOption) *Loader {
+	var o fileOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return l.step(func(cfg *Config) (*Config, error) {
+		err := cfg.envFile(filename, prefix)
+		if err != nil && o.optional && errors.Is(err, fs.ErrNotExist) {
+			err = nil
+		}
+		return cfg, err
+	})
+}
+
+// Flags overrides existing keys from the flags set in flags, which must have
+// been parsed by the caller. Flags are named like the ones of Args(), with
+// dashes instead of dots, so -server-port sets "server.port"; flags that
+// don't match a key are ignored.
+func (l *Loader) Flags(flags *flag.FlagSet) *Loader {
+	return l.step(func(cfg *Config) (*Config, error) {
+		if !flags.Parsed() {
+			return nil, fmt.Errorf("flag set %q has not been parsed", flags.Name())
+		}
+		paths := cfg.defineFlags(flag.NewFlagSet("", flag.ContinueOnError))
+		return cfg, cfg.setFlags(flags.Visit, paths)
+	})
+}
+
+// Args overrides existing keys from command line arguments, like Args().
+func (l *Loader) Args(args ...string) *Loader {
+	return l.step(func(cfg *Config) (*Config, error) {
+		return cfg, cfg.Args(args...).Error()
+	})
+}
+
+// GNUArgs overrides existing keys from GNU style command line arguments,
+// like GNUArgs().
+func (l *Loader) GNUArgs(args ...string) *Loader {
+	return l.step(func(cfg *Config) (*Config, error) {
+		return cfg, cfg.GNUArgs(args...).Error()
+	})
+}
+
+// ConfigFlag and ConfigEnv name the flag and the environment variable that
+// ConfigArgs() reads config files from.
+const (
+	ConfigFlag = "config"
+	ConfigEnv  = "APP_CONFIG"
+)
+
+// ConfigArgs merges the config files named by the --config flag of args,
+// then overrides keys from the other arguments, like Args(). The flag is
+// written -config or --config and can be repeated or list files separated
+// by commas; without it, the files are read from the APP_CONFIG
+// environment variable. Files are merged in order with Extend() semantics,
+// and their format is chosen by extension.
+//
+// Like other flags, -config is only read before the first positional
+// argument and before --. The loaded configuration lists it in Usage() and
+// accepts it in Args(); Flag() and BindFlags() don't define it, so it is
+// only available through ConfigArgs() and ParseArgs().
+func (l *Loader) ConfigArgs(args ...string) *Loader {
+	return l.step(func(cfg *Config) (*Config, error) {
+		// The flags, and so where the positional arguments start, are only
+		// known once the files are loaded: load every file named before --,
+		// then again if some of them turn out to follow a positional
+		// argument.
+		files := scanConfigArgs(args)
+		next, err := loadConfigFiles(cfg, files)
+		if err != nil {
+			return nil, err
+		}
+		if parsed := next.configArgs(args); !slices.Equal(parsed, files) {
+			if next, err = loadConfigFiles(cfg, parsed); err != nil {
+				return nil, err
+			}
+		}
+		next.configFlag = true
+		return next, next.Args(args...).Error()
+	})
+}
+
+// ParseArgs returns the configuration made of the config files named by the
+// --config flag of args, overridden by the other arguments. See
+// Loader.ConfigArgs().
+//
+// Example:
+//
+//	cfg, err := config.ParseArgs(os.Args...) // app -config base.yml -server-port 9000
+func ParseArgs(args ...string) (*Config, error) {
+	return NewLoader().ConfigArgs(args...).Load()
+}
+
+// loadConfigFiles returns cfg extended with files, or with the files named
+// by APP_CONFIG if there are none.
+func loadConfigFiles(cfg *Config, files []string) (*Config, error) {
+	if len(files) == 0 {
+		files = splitFileList(os.Getenv(ConfigEnv))
+	}
+	for _, filename := range files {
+		file, err := parseFileByExt(filename)
+		if err != nil {
+			return nil, err
+		}
+		if cfg, err = cfg.Extend(file); err != nil {
+			return nil, err
+		}
+	}
+	return cfg, nil
+}
+
+// scanConfigArgs returns the files named by every --config flag of args
+// before --, whether or not a positional argument comes first.
+func scanConfigArgs(args []string) []string {
+	var files []string
+	for i := 1; i < len(args) && args[i] != "--"; i++ {
+		arg := args[i]
+		name, value, hasValue := strings.Cut(strings.TrimPrefix(strings.TrimPrefix(arg, "-"), "-"), "=")
+		if !strings.HasPrefix(arg, "-") || name != ConfigFlag {
+			continue
+		}
+		if !hasValue {
+			if i+1 == len(args) {
+				break
+			}
+			i++
+			value = args[i]
+		}
+		files = append(files, splitFileList(value)...)
+	}
+	return files
+}
+
+// configArgs returns the files named by the --config flags that the flags
+// of c parse in args, without setting any key.
+func (c *Config) configArgs(args []string) []string {
+	if len(args) <= 1 {
+		return nil
+	}
+	f := flag.NewFlagSet(args[0], flag.ContinueOnError)
+	f.SetOutput(io.Discard)
+	var files configFiles
+	f.Var(&files, ConfigFlag, "")
+	c.defineFlags(f)
+	_ = f.Parse(args[1:])
+	return files
+}
+
+// configFiles is the value of the -config flag, collecting the files named
+// by all its occurrences.
+type configFiles []string
+
+// String implements flag.Value.
+func (f *configFiles) String() string {
+	if f == nil {
+		return ""
+	}
+	return strings.Join(*f, ",")
+}
+
+// Set implements flag.Value.
+func (f *configFiles) Set(s string) error {
+	*f = append(*f, splitFileList(s)...)
+	return nil
+}
+
+// splitFileList splits a comma-separated list of files.
+func splitFileList(list string) []string {
+	var files []string
+	for _, file := range strings.Split(list, ",") {
+		if file = strings.TrimSpace(file); file != "" {
+			files = append(files, file)
+		}
+	}
+	return files
+}
+
+// Load applies all layers in order and returns the merged configuration.
+//
+// A failing layer doesn't stop the others: the errors of all failed
+// layers are joined together, and the returned config holds the layers
+// that succeeded.
+func (l *Loader) Load() (*Config, error) {
+	cfg := &Config{Root: map[string]any{}}
+	var errs []error
+	for _, step := range l.steps {
+		next, err := step(cfg)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		cfg = next
+	}
+	return cfg, errors.Join(errs...)
+}
+
+// step appends a layer to the loader.
+func (l *Loader) step(fn func(*Config) (*Config, error)) *Loader {
+	l.steps = append(l.steps, fn)
+	return l
+}
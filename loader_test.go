@@ -0,0 +1,140 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"flag"
+	"io/fs"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoader(t *testing.T) {
+	t.Setenv("APP_APP_ABC_DEF_GHI", "env")
+
+	flags := flag.NewFlagSet("app", flag.ContinueOnError)
+	flags.String("app-env", "", "")
+	flags.Bool("verbose", false, "")
+	assert.NoError(t, flags.Parse([]string{"-app-env=flag", "-verbose"}))
+
+	cfg, err := NewLoader().
+		File("testdata/default.yml").
+		File("testdata/dev.yml").
+		File("testdata/missing.yml", Optional()).
+		EnvPrefix("app").
+		Flags(flags).
+		Args("app", "-app-ids-0=arg").
+		Load()
+	assert.NoError(t, err)
+
+	assert.Equal(t, "flag", cfg.UString("app.env"))
+	assert.Equal(t, "env", cfg.UString("app.abc.def.ghi"))
+	assert.Equal(t, "arg", cfg.UString("app.ids.0"))
+	assert.Equal(t, "id-20", cfg.UString("app.ids.1"))
+	assert.Equal(t, "id-9", cfg.UString("app.ids.9"))
+	_, err = cfg.Get("verbose")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	sources := cfg.Explain("app.env")
+	if assert.Len(t, sources, 3) {
+		assert.Equal(t, "flag", sources[0].Kind)
+		assert.Equal(t, "testdata/dev.yml", sources[1].Name)
+		assert.Equal(t, "testdata/default.yml", sources[2].Name)
+	}
+}
+
+func TestLoaderErrors(t *testing.T) {
+	cfg, err := NewLoader().
+		File("testdata/default.yml").
+		File("testdata/missing.yml").
+		File("testdata/default.txt").
+		Flags(flag.NewFlagSet("app", flag.ContinueOnError)).
+		Args("app", "-undefined").
+		Load()
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+	assert.ErrorContains(t, err, `testdata/default.txt: unsupported file format ".txt"`)
+	assert.ErrorContains(t, err, `flag set "app" has not been parsed`)
+	assert.ErrorContains(t, err, "flag provided but not defined: -undefined")
+	assert.Equal(t, "default", cfg.UString("app.env"))
+
+	_, err = NewLoader().File("testdata/schema.json").File("watch.go").Load()
+	assert.ErrorContains(t, err, `watch.go: unsupported file format ".go"`)
+}
+
+func TestLoaderEnvScan(t *testing.T) {
+	t.Setenv("LOADER_APP__IDS__10", "id-10")
+	t.Setenv("LOADER_APP__NAME", "loader")
+
+	cfg, err := NewLoader().
+		File("testdata/default.yml").
+		EnvScan("loader", "").
+		Load()
+	assert.NoError(t, err)
+	assert.Equal(t, "id-10", cfg.UString("app.ids.10"))
+	assert.Equal(t, "loader", cfg.UString("app.name"))
+
+	t.Setenv("LOADER_APP__ENV__X", "1")
+	_, err = NewLoader().
+		File("testdata/default.yml").
+		EnvScan("loader", "").
+		Load()
+	assert.ErrorIs(t, err, ErrTypeMismatch)
+}
+
+func TestLoaderGNUArgs(t *testing.T) {
+	cfg, err := NewLoader().
+		File("testdata/default.yml").
+		GNUArgs("app", "--app-env", "prod", "--set", "app.name=gnu").
+		Load()
+	assert.NoError(t, err)
+	assert.Equal(t, "prod", cfg.UString("app.env"))
+	assert.Equal(t, "gnu", cfg.UString("app.name"))
+
+	_, err = NewLoader().
+		File("testdata/default.yml").
+		GNUArgs("app", "--app.undefined").
+		Load()
+	assert.EqualError(t, err, "unknown option --app.undefined")
+}
+
+func TestLoaderConfigArgs(t *testing.T) {
+	t.Setenv(ConfigEnv, "testdata/default.yml")
+
+	cfg, err := NewLoader().
+		ConfigArgs("app", "-app-env", "arg", "--config", "testdata/default.yml,testdata/dev.yml", "serve", "-config=testdata/app.ini").
+		Load()
+	assert.NoError(t, err)
+	assert.Equal(t, "arg", cfg.UString("app.env"))
+	assert.Equal(t, "id-20", cfg.UString("app.ids.1"))
+	// -config after the first positional argument is left to the command.
+	_, err = cfg.Get("server.port")
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.Equal(t, []string{"serve", "-config=testdata/app.ini"}, cfg.Positional())
+	assert.Contains(t, cfg.Usage(), "  -config files\n    \tmerge the config files, separated by commas\n")
+	_, err = cfg.Get("config")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	cfg, err = ParseArgs("app", "-config=testdata/app.ini", "-config", "testdata/dev.yml", "serve")
+	assert.NoError(t, err)
+	assert.Equal(t, "8080", cfg.UString("server.port"))
+	assert.Equal(t, []string{"serve"}, cfg.Positional())
+
+	cfg, err = ParseArgs("app", "-app-ids-0=env", "--", "-config", "x.yml")
+	assert.NoError(t, err)
+	assert.Equal(t, "env", cfg.UString("app.ids.0"))
+	assert.Equal(t, "default", cfg.UString("app.env"))
+	assert.Equal(t, []string{"-config", "x.yml"}, cfg.Positional())
+
+	_, err = ParseArgs("app", "-config", "testdata/missing.yml", "-app-env=x")
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+	_, err = ParseArgs("app", "--config")
+	assert.EqualError(t, err, "flag needs an argument: -config")
+
+	t.Setenv(ConfigEnv, "")
+	cfg, err = ParseArgs("app")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]any{}, cfg.Root)
+}
@@ -0,0 +1,252 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	yaml3 "gopkg.in/yaml.v3"
+)
+
+// Position is the location of a key in a configuration file.
+type Position struct {
+	File   string // file name, empty when parsed from a string
+	Line   int    // line number, starting at 1
+	Column int    // column number, starting at 1
+}
+
+// IsValid reports whether the position is known.
+func (p Position) IsValid() bool {
+	return p.Line > 0
+}
+
+// String returns the position as "file:line:column", or "line:column" when
+// the file name is unknown.
+func (p Position) String() string {
+	s := strconv.Itoa(p.Line) + ":" + strconv.Itoa(p.Column)
+	if p.File != "" {
+		s = p.File + ":" + s
+	}
+	return s
+}
+
+// Position returns where the key at the given dotted path was defined.
+//
+// Positions are recorded when parsing YAML and JSON and are carried over by
+// Get(), Copy() and Extend(). The second result is false for keys whose
+// position is unknown, e.g. keys added or changed by Set(), Env() or Args().
+//
+// Example:
+//
+//	if pos, ok := cfg.Position("server.port"); ok {
+//	    fmt.Println(pos) // config.yml:12:5
+//	}
+func (c *Config) Position(path string) (Position, bool) {
+	p, ok := c.positions()[canonicalPath(path)]
+	return p, ok
+}
+
+// positions returns the key positions of c.
+func (c *Config) positions() map[string]Position {
+	if c.safe != nil {
+		c.safe.mu.Lock()
+		defer c.safe.mu.Unlock()
+	}
+	return c.pos
+}
+
+// withoutPositions returns the positions of pos except the ones at path and
+// below it. pos is returned as is if there are none.
+func withoutPositions(pos map[string]Position, path string) map[string]Position {
+	var out map[string]Position
+	for k := range pos {
+		if k != path && !strings.HasPrefix(k, path+".") {
+			continue
+		}
+		if out == nil {
+			out = make(map[string]Position, len(pos))
+			for k, p := range pos {
+				out[k] = p
+			}
+		}
+		delete(out, k)
+	}
+	if out == nil {
+		return pos
+	}
+	return out
+}
+
+// canonicalPath returns path in the form used to key positions, with keys
+// containing dots in bracket notation.
+func canonicalPath(path string) string {
+	var out string
+	for _, part := range normalizePath(path) {
+		out = joinPath(out, part)
+	}
+	return out
+}
+
+// subPositions returns the positions below path, relative to it.
+func subPositions(pos map[string]Position, path string) map[string]Position {
+	prefix := canonicalPath(path)
+	if prefix == "" {
+		return pos
+	}
+	out := make(map[string]Position)
+	for k, p := range pos {
+		if strings.HasPrefix(k, prefix+".") {
+			out[k[len(prefix)+1:]] = p
+		}
+	}
+	return out
+}
+
+// mergePositions returns the positions of base overlaid with the ones of
+// overlay. The maps are never modified once a config is built.
+func mergePositions(base, overlay map[string]Position) map[string]Position {
+	if len(overlay) == 0 {
+		return base
+	}
+	out := make(map[string]Position, len(base)+len(overlay))
+	for k, p := range base {
+		out[k] = p
+	}
+	for k, p := range overlay {
+		out[k] = p
+	}
+	return out
+}
+
+// yamlPositions returns the position of every key and list item of each
+// document of a YAML stream. It returns nil if the stream can't be parsed.
+func yamlPositions(data []byte, file string) []map[string]Position {
+	d := yaml3.NewDecoder(bytes.NewReader(data))
+	var docs []map[string]Position
+	for {
+		var doc yaml3.Node
+		if err := d.Decode(&doc); err != nil {
+			if errors.Is(err, io.EOF) {
+				return docs
+			}
+			return nil
+		}
+		pos := make(map[string]Position)
+		walkYamlNode(&doc, "", file, pos)
+		docs = append(docs, pos)
+	}
+}
+
+// walkYamlNode records the positions of the children of n, found at path.
+func walkYamlNode(n *yaml3.Node, path, file string, pos map[string]Position) {
+	switch n.Kind {
+	case yaml3.DocumentNode:
+		for _, child := range n.Content {
+			walkYamlNode(child, path, file, pos)
+		}
+	case yaml3.MappingNode:
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			key, value := n.Content[i], n.Content[i+1]
+			if key.Value == "<<" && key.Tag == "!!merge" {
+				continue
+			}
+			p := joinPath(path, key.Value)
+			pos[p] = Position{File: file, Line: key.Line, Column: key.Column}
+			walkYamlNode(value, p, file, pos)
+		}
+	case yaml3.SequenceNode:
+		for i, item := range n.Content {
+			p := joinPath(path, strconv.Itoa(i))
+			pos[p] = Position{File: file, Line: item.Line, Column: item.Column}
+			walkYamlNode(item, p, file, pos)
+		}
+	}
+}
+
+// jsonPositions returns the position of every key and list item in a JSON
+// document. It returns nil if the document can't be parsed.
+func jsonPositions(data []byte, file string) map[string]Position {
+	w := jsonWalker{
+		data: data,
+		dec:  json.NewDecoder(bytes.NewReader(data)),
+		file: file,
+		pos:  make(map[string]Position),
+	}
+	for i, b := range data {
+		if b == '\n' {
+			w.lines = append(w.lines, i+1)
+		}
+	}
+	tok, err := w.dec.Token()
+	if err != nil || w.walk(tok, "") != nil {
+		return nil
+	}
+	return w.pos
+}
+
+// jsonWalker records positions while reading a JSON document token by token.
+type jsonWalker struct {
+	data  []byte
+	dec   *json.Decoder
+	file  string
+	lines []int // offsets at which lines after the first one start
+	pos   map[string]Position
+}
+
+// walk records the positions of the children of the value starting with
+// tok, found at path.
+func (w *jsonWalker) walk(tok json.Token, path string) error {
+	delim, ok := tok.(json.Delim)
+	if !ok {
+		return nil
+	}
+	for i := 0; w.dec.More(); i++ {
+		start := w.start()
+		tok, err := w.dec.Token()
+		if err != nil {
+			return err
+		}
+		var p string
+		if delim == '{' {
+			p = joinPath(path, tok.(string))
+			if tok, err = w.dec.Token(); err != nil {
+				return err
+			}
+		} else {
+			p = joinPath(path, strconv.Itoa(i))
+		}
+		w.pos[p] = w.position(start)
+		if err = w.walk(tok, p); err != nil {
+			return err
+		}
+	}
+	_, err := w.dec.Token() // closing delimiter
+	return err
+}
+
+// start returns the offset of the next token.
+func (w *jsonWalker) start() int {
+	i := int(w.dec.InputOffset())
+	for i < len(w.data) && strings.IndexByte(" \t\r\n,:", w.data[i]) >= 0 {
+		i++
+	}
+	return i
+}
+
+// position converts an offset into a position.
+func (w *jsonWalker) position(offset int) Position {
+	line := sort.SearchInts(w.lines, offset+1)
+	lineStart := 0
+	if line > 0 {
+		lineStart = w.lines[line-1]
+	}
+	return Position{File: w.file, Line: line + 1, Column: offset - lineStart + 1}
+}
@@ -0,0 +1,146 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPositionYaml(t *testing.T) {
+	cfg, err := ParseYamlFile("testdata/default.yml")
+	assert.NoError(t, err)
+
+	pos, ok := cfg.Position("app.env")
+	assert.True(t, ok)
+	assert.Equal(t, Position{File: "testdata/default.yml", Line: 2, Column: 3}, pos)
+	assert.Equal(t, "testdata/default.yml:2:3", pos.String())
+
+	pos, ok = cfg.Position("app.ids.2")
+	assert.True(t, ok)
+	assert.Equal(t, 3, pos.Line)
+	assert.Equal(t, 26, pos.Column)
+
+	_, ok = cfg.Position("app.undefined")
+	assert.False(t, ok)
+
+	cfg, err = ParseYaml(`
+server:
+  "my.host": example.com
+  ports:
+    - 80
+    - 443
+`)
+	assert.NoError(t, err)
+	pos, _ = cfg.Position("server.[my.host]")
+	assert.Equal(t, Position{Line: 3, Column: 3}, pos)
+	assert.Equal(t, "3:3", pos.String())
+	pos, _ = cfg.Position("server.ports.1")
+	assert.Equal(t, Position{Line: 6, Column: 7}, pos)
+}
+
+func TestPositionJson(t *testing.T) {
+	cfg, err := ParseJson(`{
+  "server": {
+    "port": 8080,
+    "hosts": ["a", {"name": "b"}]
+  }
+}`)
+	assert.NoError(t, err)
+
+	pos, _ := cfg.Position("server")
+	assert.Equal(t, Position{Line: 2, Column: 3}, pos)
+	pos, _ = cfg.Position("server.port")
+	assert.Equal(t, Position{Line: 3, Column: 5}, pos)
+	pos, _ = cfg.Position("server.hosts.1")
+	assert.Equal(t, Position{Line: 4, Column: 20}, pos)
+	pos, _ = cfg.Position("server.hosts.1.name")
+	assert.Equal(t, Position{Line: 4, Column: 21}, pos)
+}
+
+func TestPositionCarriedOver(t *testing.T) {
+	base, err := ParseYaml("server:\n  host: localhost\n  port: 8080\n")
+	assert.NoError(t, err)
+	overlay, err := ParseYaml("\n\nserver:\n  port: 9000\n")
+	assert.NoError(t, err)
+
+	sub, err := base.Get("server")
+	assert.NoError(t, err)
+	pos, _ := sub.Position("port")
+	assert.Equal(t, 3, pos.Line)
+
+	cp, err := base.Copy("server")
+	assert.NoError(t, err)
+	pos, _ = cp.Position("host")
+	assert.Equal(t, 2, pos.Line)
+
+	merged, err := base.Extend(overlay)
+	assert.NoError(t, err)
+	pos, _ = merged.Position("server.host")
+	assert.Equal(t, 2, pos.Line)
+	pos, _ = merged.Position("server.port")
+	assert.Equal(t, 4, pos.Line)
+
+	safe := NewSafe(merged)
+	pos, _ = safe.Position("server.port")
+	assert.Equal(t, 4, pos.Line)
+}
+
+func TestPositionDroppedOnChange(t *testing.T) {
+	cfg, err := ParseYaml("server:\n  host: localhost\n  port: 8080\nids: [1, 2]\n")
+	assert.NoError(t, err)
+	cp, err := cfg.Copy()
+	assert.NoError(t, err)
+
+	assert.NoError(t, cfg.Set("server.port", 9000))
+	_, ok := cfg.Position("server.port")
+	assert.False(t, ok)
+	_, ok = cfg.Position("server.host")
+	assert.True(t, ok)
+	_, ok = cp.Position("server.port")
+	assert.True(t, ok)
+
+	t.Setenv("POS_IDS", "3,4")
+	cfg.EnvPrefix("pos")
+	_, ok = cfg.Position("ids.0")
+	assert.False(t, ok)
+
+	assert.NoError(t, cfg.Set("server", map[string]any{"host": "example.com"}))
+	_, ok = cfg.Position("server")
+	assert.False(t, ok)
+	_, ok = cfg.Position("server.host")
+	assert.False(t, ok)
+
+	safe := NewSafe(cp)
+	safe.Args("app", "-server-host=x")
+	_, ok = safe.Position("server.host")
+	assert.False(t, ok)
+	_, ok = safe.Position("server.port")
+	assert.True(t, ok)
+}
+
+func TestPositionInErrors(t *testing.T) {
+	cfg, err := ParseYaml("server:\n  port: http\n")
+	assert.NoError(t, err)
+
+	_, err = cfg.Int("server.port")
+	assert.ErrorIs(t, err, ErrTypeMismatch)
+	assert.ErrorContains(t, err, `2:3: int "server.port": type mismatch`)
+
+	var srv struct{ Port int }
+	err = cfg.Unmarshal("server", &srv)
+	assert.ErrorContains(t, err, `2:3: unmarshal "server.port"`)
+
+	err = cfg.Validate(&Schema{
+		Properties: map[string]*Schema{
+			"server": {Properties: map[string]*Schema{"port": {Type: "integer"}}},
+		},
+	})
+	assert.ErrorContains(t, err, `2:3: validate "server.port"`)
+
+	_, err = cfg.Int("server.undefined")
+	assert.EqualError(t, err, `int "server.undefined": key not found`)
+}
@@ -0,0 +1,230 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// ProfileEnv and ProfileKey name the environment variable and the key of
+// default.yml that LoadProfile() reads the active profile from, and
+// ProfileSelector the key tagging the documents of a YAML stream that only
+// apply to some profiles.
+const (
+	ProfileEnv      = "APP_PROFILE"
+	ProfileKey      = "profiles.active"
+	ProfileSelector = "profile"
+)
+
+// ParseYamlProfile parses a YAML configuration from the given string,
+// merging its documents for profile.
+//
+// The documents of a stream separated by --- are merged in order with
+// Extend() semantics. Documents with a "profile" key only apply to the
+// profile it names, or to any of the profiles it lists; the others always
+// apply. The "profile" key itself is left out of the result, even in a
+// stream of a single document, and the result is an empty map if no
+// document applies.
+//
+// Example:
+//
+//	server:
+//	  port: 8080
+//	---
+//	profile: prod
+//	server:
+//	  port: 80
+func ParseYamlProfile(cfg, profile string) (*Config, error) {
+	return parseYamlProfile([]byte(cfg), "", profile)
+}
+
+// ParseYamlProfileFile reads a YAML configuration from the given filename,
+// merging its documents for profile. See ParseYamlProfile().
+func ParseYamlProfileFile(filename, profile string) (*Config, error) {
+	cfg, err := os.ReadFile(filepath.Clean(filename))
+	if err != nil {
+		return nil, err
+	}
+	return parseYamlProfile(cfg, filename, profile)
+}
+
+// LoadProfile returns the configuration of a profile, merged with Extend()
+// semantics from the files of dir:
+//
+//   - default.yml, which is required;
+//   - <profile>.yml, required when a profile is active;
+//   - <profile>.local.yml, if present, for overrides kept out of version
+//     control.
+//
+// The active profile is the given one, or else the value of the
+// APP_PROFILE environment variable, or else the "profiles.active" key of
+// default.yml. Without a profile only default.yml is loaded. Files may hold
+// several documents, merged for the active profile like
+// ParseYamlProfile() does.
+//
+// Example:
+//
+//	cfg, err := config.LoadProfile("config", "") // config/default.yml + config/$APP_PROFILE.yml
+func LoadProfile(dir, profile string) (*Config, error) {
+	base := filepath.Join(dir, "default.yml")
+	if profile == "" {
+		profile = os.Getenv(ProfileEnv)
+	}
+	if profile == "" {
+		cfg, err := parseProfileFile(base, "")
+		if err != nil {
+			return nil, err
+		}
+		if profile, _ = cfg.String(ProfileKey); profile == "" {
+			return cfg, nil
+		}
+	}
+	if profile != filepath.Base(profile) || profile == "." || profile == ".." {
+		return nil, fmt.Errorf("invalid profile %q", profile)
+	}
+
+	cfg, err := parseProfileFile(base, profile)
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range []string{profile + ".yml", profile + ".local.yml"} {
+		file, err := parseProfileFile(filepath.Join(dir, name), profile)
+		if err != nil {
+			if name != profile+".yml" && errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			return nil, err
+		}
+		if cfg, err = cfg.Extend(file); err != nil {
+			return nil, err
+		}
+	}
+	return cfg, nil
+}
+
+// parseProfileFile reads a YAML file for profile. Parse errors are prefixed
+// with the file name.
+func parseProfileFile(filename, profile string) (*Config, error) {
+	data, err := os.ReadFile(filepath.Clean(filename))
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := parseYamlProfile(data, filename, profile)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	return cfg, nil
+}
+
+// parseYamlProfile performs the real YAML parsing, merging the documents of
+// the stream that apply to profile.
+func parseYamlProfile(data []byte, filename, profile string) (*Config, error) {
+	return parseYamlStream(data, filename, profile, true)
+}
+
+// parseYamlStream parses the documents of a YAML stream and merges the ones
+// that apply to profile. Unless always is true, the documents of a stream
+// of a single document are not selected, and its profile key is kept as an
+// ordinary key.
+func parseYamlStream(data []byte, filename, profile string, always bool) (*Config, error) {
+	var docs []any
+	d := yaml.NewDecoder(bytes.NewReader(data))
+	for {
+		var out any
+		if err := d.Decode(&out); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, &PathError{Op: "parse", Err: err}
+		}
+		out, err := normalizeValue(out)
+		if err != nil {
+			return nil, err
+		}
+		docs = append(docs, out)
+	}
+	if len(docs) == 0 && !always {
+		return newParsed(nil, filename, nil), nil
+	}
+
+	positions := yamlPositions(data, filename)
+	if len(positions) != len(docs) {
+		positions = make([]map[string]Position, len(docs))
+	}
+	if len(docs) == 1 && !always {
+		return newParsed(docs[0], filename, positions[0]), nil
+	}
+
+	var cfg *Config
+	for i, doc := range docs {
+		if doc == nil {
+			// Empty documents, e.g. after a trailing ---, add nothing.
+			continue
+		}
+		pos := positions[i]
+		ok, err := selectDocument(doc, profile)
+		if err != nil {
+			p := pos[ProfileSelector]
+			return nil, &PathError{Op: "parse", Path: ProfileSelector, Pos: p, Err: err}
+		}
+		if !ok {
+			continue
+		}
+		for k := range pos {
+			if k == ProfileSelector || strings.HasPrefix(k, ProfileSelector+".") {
+				delete(pos, k)
+			}
+		}
+		next := newParsed(doc, filename, pos)
+		if cfg == nil {
+			cfg = next
+		} else if cfg, err = cfg.Extend(next); err != nil {
+			return nil, err
+		}
+	}
+	if cfg == nil {
+		cfg = newParsed(map[string]any{}, filename, nil)
+	}
+	return cfg, nil
+}
+
+// selectDocument reports whether a document of a YAML stream applies to
+// profile, and removes its selector key.
+func selectDocument(doc any, profile string) (bool, error) {
+	m, ok := doc.(map[string]any)
+	if !ok {
+		return true, nil
+	}
+	tag, ok := m[ProfileSelector]
+	if !ok {
+		return true, nil
+	}
+	delete(m, ProfileSelector)
+
+	var profiles []any
+	switch tag := tag.(type) {
+	case string:
+		profiles = []any{tag}
+	case []any:
+		profiles = tag
+	default:
+		return false, typeMismatch("string or list", tag)
+	}
+	for _, p := range profiles {
+		if s, ok := p.(string); ok && s != "" && s == profile {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,160 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadProfile(t *testing.T) {
+	t.Setenv(ProfileEnv, "")
+
+	cfg, err := LoadProfile("testdata", "dev")
+	assert.NoError(t, err)
+	assert.Equal(t, "dev", cfg.UString("app.env"))
+	assert.Equal(t, "id-20", cfg.UString("app.ids.1"))
+	assert.Equal(t, "id-9", cfg.UString("app.ids.9"))
+	assert.Equal(t, "testdata/dev.yml", cfg.Explain("app.env")[0].Name)
+
+	cfg, err = LoadProfile("testdata", "")
+	assert.NoError(t, err)
+	assert.Equal(t, "default", cfg.UString("app.env"))
+
+	t.Setenv(ProfileEnv, "dev")
+	cfg, err = LoadProfile("testdata", "")
+	assert.NoError(t, err)
+	assert.Equal(t, "dev", cfg.UString("app.env"))
+
+	_, err = LoadProfile("testdata", "prod")
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+	_, err = LoadProfile("testdata", "../dev")
+	assert.EqualError(t, err, `invalid profile "../dev"`)
+	_, err = LoadProfile("testdata/missing", "dev")
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+}
+
+func TestLoadProfileLocal(t *testing.T) {
+	t.Setenv(ProfileEnv, "")
+	dir := t.TempDir()
+	write := func(name, content string) {
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600))
+	}
+	write("default.yml", "profiles:\n  active: prod\nserver:\n  host: localhost\n  port: 8080\n")
+	write("prod.yml", "server:\n  host: example.com\n")
+
+	cfg, err := LoadProfile(dir, "")
+	assert.NoError(t, err)
+	assert.Equal(t, "example.com", cfg.UString("server.host"))
+	assert.Equal(t, 8080, cfg.UInt("server.port"))
+
+	write("prod.local.yml", "server:\n  port: 9000\n")
+	cfg, err = NewLoader().Profile(dir, "").EnvPrefix("app").Load()
+	assert.NoError(t, err)
+	assert.Equal(t, "example.com", cfg.UString("server.host"))
+	assert.Equal(t, 9000, cfg.UInt("server.port"))
+
+	write("prod.local.yml", "server: [")
+	_, err = LoadProfile(dir, "")
+	assert.ErrorContains(t, err, "prod.local.yml")
+}
+
+const profileYaml = `
+server:
+  host: localhost
+  port: 8080
+ids: [a, b]
+---
+profile: prod
+server:
+  host: example.com
+---
+profile: [dev, test]
+server:
+  port: 9000
+---
+debug: false
+`
+
+func TestParseYamlProfile(t *testing.T) {
+	cfg, err := ParseYaml(profileYaml)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]any{
+		"server": map[string]any{"host": "localhost", "port": 8080},
+		"ids":    []any{"a", "b"},
+		"debug":  false,
+	}, cfg.Root)
+
+	cfg, err = ParseYamlProfile(profileYaml, "prod")
+	assert.NoError(t, err)
+	assert.Equal(t, "example.com", cfg.UString("server.host"))
+	assert.Equal(t, 8080, cfg.UInt("server.port"))
+	_, err = cfg.Get(ProfileSelector)
+	assert.ErrorIs(t, err, ErrNotFound)
+	pos, ok := cfg.Position("server.host")
+	assert.True(t, ok)
+	assert.Equal(t, Position{Line: 9, Column: 3}, pos)
+	_, ok = cfg.Position(ProfileSelector)
+	assert.False(t, ok)
+
+	cfg, err = ParseYamlProfile(profileYaml, "test")
+	assert.NoError(t, err)
+	assert.Equal(t, "localhost", cfg.UString("server.host"))
+	assert.Equal(t, 9000, cfg.UInt("server.port"))
+	assert.Equal(t, false, cfg.UBool("debug", true))
+
+	// A single document is selected too, with or without a trailing ---.
+	for _, doc := range []string{"profile: prod\ndb: prod-db\n", "profile: prod\ndb: prod-db\n---\n"} {
+		cfg, err = ParseYamlProfile(doc, "dev")
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]any{}, cfg.Root)
+
+		cfg, err = ParseYamlProfile(doc, "prod")
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]any{"db": "prod-db"}, cfg.Root)
+	}
+	cfg, err = ParseYamlProfile("", "prod")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]any{}, cfg.Root)
+
+	// Plain ParseYaml keeps the profile key of a single document.
+	cfg, err = ParseYaml("profile: prod\nport: 80\n")
+	assert.NoError(t, err)
+	assert.Equal(t, "prod", cfg.UString(ProfileSelector))
+
+	cfg, err = ParseYaml("")
+	assert.NoError(t, err)
+	assert.Nil(t, cfg.Root)
+
+	_, err = ParseYamlProfile("a: 1\n---\nprofile: {x: y}\n", "x")
+	assert.ErrorIs(t, err, ErrTypeMismatch)
+	assert.ErrorContains(t, err, `3:1: parse "profile"`)
+	_, err = ParseYaml("a: 1\n---\nb: [\n")
+	assert.ErrorContains(t, err, "parse: yaml: line 3")
+}
+
+func TestLoadProfileDocuments(t *testing.T) {
+	t.Setenv(ProfileEnv, "")
+	dir := t.TempDir()
+	write := func(name, content string) {
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600))
+	}
+	write("default.yml", "profiles:\n  active: prod\n"+profileYaml)
+	write("prod.yml", "server:\n  port: 443\n---\nprofile: dev\nserver:\n  port: 1\n")
+
+	cfg, err := LoadProfile(dir, "")
+	assert.NoError(t, err)
+	assert.Equal(t, "example.com", cfg.UString("server.host"))
+	assert.Equal(t, 443, cfg.UInt("server.port"))
+
+	cfg, err = ParseYamlProfileFile(filepath.Join(dir, "default.yml"), "test")
+	assert.NoError(t, err)
+	assert.Equal(t, 9000, cfg.UInt("server.port"))
+	assert.Equal(t, filepath.Join(dir, "default.yml"), cfg.Explain("server.port")[0].Name)
+}
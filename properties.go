@@ -0,0 +1,232 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ParseProperties parses a Java .properties file from the given string.
+//
+// Keys and values are separated by =, : or whitespace. Lines starting with
+// # or ! are comments, a line ending with a backslash continues on the
+// next one, and the \t, \n, \r, \f and \uXXXX escapes are supported.
+//
+// Dotted keys map directly onto dotted paths, so server.port=9000 is found
+// at "server.port". A key that is a prefix of another one is kept whole,
+// along with the longer keys, like ParseDotenv() does: with
+// log4j.appender.A1=x and log4j.appender.A1.layout=y, the keys are
+// "[log4j.appender.A1]" and "[log4j.appender.A1.layout]". All values are
+// strings.
+func ParseProperties(cfg string) (*Config, error) {
+	return parseProperties([]byte(cfg), "")
+}
+
+// ParsePropertiesFile reads a Java .properties file from the given filename.
+// See ParseProperties() for the syntax.
+func ParsePropertiesFile(filename string) (*Config, error) {
+	cfg, err := os.ReadFile(filepath.Clean(filename))
+	if err != nil {
+		return nil, err
+	}
+	return parseProperties(cfg, filename)
+}
+
+// RenderProperties renders a configuration as a Java .properties file, the
+// reverse of ParseProperties(). Every value is written on its own line under
+// its dotted path, and lines are sorted by key.
+func RenderProperties(cfg any) (string, error) {
+	var lines []string
+	for _, key := range getKeys(cfg) {
+		if len(key) == 0 {
+			return "", typeMismatch("map or list", cfg)
+		}
+		var path string
+		for _, part := range key {
+			path = joinPath(path, part)
+		}
+		value, err := get("render", cfg, path)
+		if err != nil {
+			return "", err
+		}
+		var s string
+		switch value.(type) {
+		case nil, map[string]any, []any:
+			// empty values
+		default:
+			if s, err = toString(value); err != nil {
+				return "", &PathError{Op: "render", Path: path, Err: err}
+			}
+		}
+		parts := make([]string, len(key))
+		for i, part := range key {
+			parts[i] = escapeProperty(part, true)
+		}
+		lines = append(lines, strings.Join(parts, ".")+"="+escapeProperty(s, false)+"\n")
+	}
+	sort.Strings(lines)
+	return strings.Join(lines, ""), nil
+}
+
+// parseProperties performs the real .properties parsing.
+func parseProperties(cfg []byte, filename string) (*Config, error) {
+	lines := strings.Split(strings.ReplaceAll(string(cfg), "\r\n", "\n"), "\n")
+	var names [][]string
+	var values []string
+	var positions []Position
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimLeft(lines[i], " \t\f")
+		if line == "" || line[0] == '#' || line[0] == '!' {
+			continue
+		}
+		p := Position{File: filename, Line: i + 1, Column: len(lines[i]) - len(line) + 1}
+
+		// Join continuation lines, dropping their leading whitespace.
+		for continuesLine(line) {
+			line = line[:len(line)-1]
+			if i+1 == len(lines) {
+				break
+			}
+			i++
+			line += strings.TrimLeft(lines[i], " \t\f")
+		}
+
+		rawKey, rawValue := splitProperty(line)
+		key, err := unescapeProperty(rawKey)
+		if err == nil && key == "" {
+			err = fmt.Errorf("missing key in %q", line)
+		}
+		if err != nil {
+			return nil, &PathError{Op: "parse", Pos: p, Err: err}
+		}
+		value, err := unescapeProperty(rawValue)
+		if err != nil {
+			return nil, &PathError{Op: "parse", Path: key, Pos: p, Err: err}
+		}
+
+		parts := strings.Split(key, ".")
+		for _, part := range parts {
+			if part == "" {
+				return nil, &PathError{Op: "parse", Path: key, Pos: p, Err: ErrInvalidPath}
+			}
+		}
+		names = append(names, parts)
+		values = append(values, value)
+		positions = append(positions, p)
+	}
+
+	root := map[string]any{}
+	pos := make(map[string]Position)
+	for i, parts := range keepPrefixesWhole(names, ".") {
+		path, err := insertFlat(root, parts, values[i])
+		if err != nil {
+			return nil, &PathError{Op: "parse", Path: path, Pos: positions[i], Err: err}
+		}
+		pos[path] = positions[i]
+	}
+	return newParsed(root, filename, pos), nil
+}
+
+// continuesLine reports whether line ends with an odd number of backslashes.
+func continuesLine(line string) bool {
+	n := 0
+	for i := len(line) - 1; i >= 0 && line[i] == '\\'; i-- {
+		n++
+	}
+	return n%2 == 1
+}
+
+// splitProperty splits a logical line into its raw key and value.
+func splitProperty(line string) (string, string) {
+	for i := 0; i < len(line); i++ {
+		switch line[i] {
+		case '\\':
+			i++
+		case '=', ':':
+			return line[:i], strings.TrimLeft(line[i+1:], " \t\f")
+		case ' ', '\t', '\f':
+			rest := strings.TrimLeft(line[i:], " \t\f")
+			if rest != "" && (rest[0] == '=' || rest[0] == ':') {
+				rest = strings.TrimLeft(rest[1:], " \t\f")
+			}
+			return line[:i], rest
+		}
+	}
+	return line, ""
+}
+
+// unescapeProperty expands the escape sequences of a key or value.
+func unescapeProperty(s string) (string, error) {
+	if !strings.Contains(s, `\`) {
+		return s, nil
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' {
+			b.WriteByte(s[i])
+			continue
+		}
+		if i+1 == len(s) {
+			break
+		}
+		i++
+		switch c := s[i]; c {
+		case 't':
+			b.WriteByte('\t')
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case 'f':
+			b.WriteByte('\f')
+		case 'u':
+			if i+5 > len(s) {
+				return "", fmt.Errorf("invalid escape %q", s[i-1:])
+			}
+			r, err := strconv.ParseUint(s[i+1:i+5], 16, 16)
+			if err != nil {
+				return "", fmt.Errorf("invalid escape %q", s[i-1:i+5])
+			}
+			b.WriteRune(rune(r))
+			i += 4
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String(), nil
+}
+
+// escapeProperty escapes s for use as a key part or a value.
+func escapeProperty(s string, key bool) string {
+	var b strings.Builder
+	for i, r := range s {
+		switch r {
+		case '\\':
+			b.WriteString(`\\`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		case '\f':
+			b.WriteString(`\f`)
+		case ' ', '=', ':', '#', '!':
+			// Values only need a leading space escaped.
+			if key || (r == ' ' && i == 0) {
+				b.WriteByte('\\')
+			}
+			b.WriteRune(r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
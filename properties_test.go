@@ -0,0 +1,90 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParsePropertiesFile(t *testing.T) {
+	cfg, err := ParsePropertiesFile("testdata/app.properties")
+	assert.NoError(t, err)
+
+	assert.Equal(t, "localhost", cfg.UString("server.host"))
+	assert.Equal(t, 8080, cfg.UInt("server.port"))
+	assert.Equal(t, "My App", cfg.UString("server.name"))
+	assert.Equal(t, "Hello, world", cfg.UString("app.greeting"))
+	assert.Equal(t, `C:\temp`, cfg.UString("app.path"))
+	assert.Equal(t, "café", cfg.UString("app.unicode"))
+	assert.Equal(t, "db2", cfg.UString("db.replicas.1"))
+
+	pos, _ := cfg.Position("app.greeting")
+	assert.Equal(t, "testdata/app.properties:6:1", pos.String())
+
+	cfg, err = ParseFile("testdata/app.properties")
+	assert.NoError(t, err)
+	assert.Equal(t, "localhost", cfg.UString("server.host"))
+}
+
+func TestParsePropertiesErrors(t *testing.T) {
+	_, err := ParseProperties("a..b=1")
+	assert.ErrorIs(t, err, ErrInvalidPath)
+
+	_, err = ParseProperties("=1")
+	assert.EqualError(t, err, `1:1: parse: missing key in "=1"`)
+
+	_, err = ParseProperties(`a=\u00zz`)
+	assert.EqualError(t, err, `1:1: parse "a": invalid escape "\\u00zz"`)
+}
+
+func TestParsePropertiesPrefixKeys(t *testing.T) {
+	cfg, err := ParseProperties(`log4j.rootLogger=DEBUG, A1
+log4j.appender.A1=org.apache.log4j.ConsoleAppender
+log4j.appender.A1.layout=org.apache.log4j.PatternLayout
+log4j.appender.A1.layout.ConversionPattern=%m%n
+`)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]any{
+		"log4j":                    map[string]any{"rootLogger": "DEBUG, A1"},
+		"log4j.appender.A1":        "org.apache.log4j.ConsoleAppender",
+		"log4j.appender.A1.layout": "org.apache.log4j.PatternLayout",
+		"log4j.appender.A1.layout.ConversionPattern": "%m%n",
+	}, cfg.Root)
+	assert.Equal(t, "org.apache.log4j.PatternLayout", cfg.UString("[log4j.appender.A1.layout]"))
+	pos, _ := cfg.Position("[log4j.appender.A1.layout]")
+	assert.Equal(t, "3:1", pos.String())
+
+	cfg, err = ParseProperties("a.b=2\na=1\n")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]any{"a": "1", "a.b": "2"}, cfg.Root)
+
+	out, err := RenderProperties(cfg.Root)
+	assert.NoError(t, err)
+	assert.Equal(t, "a.b=2\na=1\n", out)
+	again, err := ParseProperties(out)
+	assert.NoError(t, err)
+	assert.Equal(t, cfg.Root, again.Root)
+}
+
+func TestRenderProperties(t *testing.T) {
+	out, err := RenderProperties(map[string]any{
+		"server":  map[string]any{"port": 8080, "name": " My App"},
+		"odd key": "a=b\nc",
+		"list":    []any{"x", "y"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "list.0=x\nlist.1=y\nodd\\ key=a=b\\nc\nserver.name=\\ My App\nserver.port=8080\n", out)
+
+	cfg, err := ParseProperties(out)
+	assert.NoError(t, err)
+	assert.Equal(t, " My App", cfg.UString("server.name"))
+	assert.Equal(t, "a=b\nc", cfg.UString("odd key"))
+	assert.Equal(t, "y", cfg.UString("list.1"))
+
+	_, err = RenderProperties(42)
+	assert.ErrorIs(t, err, ErrTypeMismatch)
+}
@@ -0,0 +1,172 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"strings"
+)
+
+// Source describes a layer that set the value of a key.
+type Source struct {
+	Kind  string   // "file", "env", "flag" or "set"
+	Name  string   // file name, environment variable or flag name
+	Pos   Position // position in the file, if known
+	Value any      // value set by this layer
+}
+
+// String returns a short description of the source, e.g.
+// "file config.yml:12:5" or "env APP_SERVER_PORT".
+func (s Source) String() string {
+	name := s.Name
+	if s.Pos.IsValid() {
+		name = s.Pos.String()
+	}
+	if name == "" {
+		return s.Kind
+	}
+	return s.Kind + " " + name
+}
+
+// Explain returns the layers that set the key at the given dotted path,
+// most recent first: the first source is the one whose value is in effect
+// and the others are the values it shadows.
+//
+// Layers are recorded by the parse functions, Extend(), Env(), EnvPrefix(),
+// Flag(), Args() and Set(). Consecutive writes by the same layer keep only
+// the last value, and the history is capped at 16 sources, keeping the
+// oldest one. Explain returns nil for unknown paths and for maps and lists,
+// which are not set by a single layer.
+//
+// Example:
+//
+//	for _, src := range cfg.Explain("server.port") {
+//	    fmt.Println(src, src.Value) // env APP_SERVER_PORT 9000
+//	}                               // file prod.yml:3:3 8080
+func (c *Config) Explain(path string) []Source {
+	if c.safe != nil {
+		c.safe.mu.Lock()
+		defer c.safe.mu.Unlock()
+	}
+	history := c.sources[canonicalPath(path)]
+	if len(history) == 0 {
+		return nil
+	}
+	out := make([]Source, len(history))
+	for i, src := range history {
+		out[len(history)-1-i] = src
+	}
+	return out
+}
+
+// maxSources is the number of sources kept in the history of a key. The
+// oldest source, usually the file that defined the key, is always kept.
+const maxSources = 16
+
+// record appends src to the history of path, and forgets the positions of
+// the keys it changed. Consecutive writes by the same source, e.g. repeated
+// Set() calls, replace each other, and the history of the keys below path
+// is cleared since their values were replaced. Configs created by NewSafe
+// must call it while holding the lock, i.e. from an update() function.
+func (c *Config) record(path string, src Source) {
+	if c.sources == nil {
+		c.sources = make(map[string][]Source)
+	}
+	path = canonicalPath(path)
+	for k := range c.sources {
+		if strings.HasPrefix(k, path+".") {
+			delete(c.sources, k)
+		}
+	}
+
+	history := c.sources[path]
+	if n := len(history); n > 0 && sameSource(history[n-1], src) {
+		history[n-1] = src
+	} else {
+		history = append(history, src)
+	}
+	if len(history) > maxSources {
+		history = append(history[:1], history[len(history)-maxSources+1:]...)
+	}
+	c.sources[path] = history
+	c.pos = withoutPositions(c.pos, path)
+}
+
+// sameSource reports whether a and b come from the same layer.
+func sameSource(a, b Source) bool {
+	return a.Kind == b.Kind && a.Name == b.Name && a.Pos.File == b.Pos.File
+}
+
+// sourcesBelow returns a copy of the history below path, relative to it.
+func (c *Config) sourcesBelow(path string) map[string][]Source {
+	if c.safe != nil {
+		c.safe.mu.Lock()
+		defer c.safe.mu.Unlock()
+	}
+	return subSources(c.sources, path)
+}
+
+// fileSources returns the history of a freshly parsed tree, with every
+// value set by the given file.
+func fileSources(root any, filename string, pos map[string]Position) map[string][]Source {
+	sources := make(map[string][]Source)
+	for _, key := range getKeys(root) {
+		var path string
+		for _, part := range key {
+			path = joinPath(path, part)
+		}
+		if path == "" {
+			continue
+		}
+		value, _ := get("get", root, path)
+		sources[path] = []Source{{Kind: "file", Name: filename, Pos: pos[path], Value: value}}
+	}
+	return sources
+}
+
+// newParsed returns the config for a tree parsed from filename.
+func newParsed(root any, filename string, pos map[string]Position) *Config {
+	return &Config{Root: root, pos: pos, sources: fileSources(root, filename, pos)}
+}
+
+// subSources returns a copy of the history below path, relative to it.
+func subSources(sources map[string][]Source, path string) map[string][]Source {
+	prefix := canonicalPath(path)
+	out := make(map[string][]Source)
+	for k, history := range sources {
+		switch {
+		case prefix == "":
+		case strings.HasPrefix(k, prefix+"."):
+			k = k[len(prefix)+1:]
+		default:
+			continue
+		}
+		out[k] = append([]Source(nil), history...)
+	}
+	return out
+}
+
+// setBelow reports whether one of the canonical paths of set is an
+// ancestor of path, or path itself if self is true.
+func setBelow(set map[string]bool, path string, self bool) bool {
+	parts := normalizePath(path)
+	var p string
+	for i, part := range parts {
+		p = joinPath(p, part)
+		if set[p] && (self || i < len(parts)-1) {
+			return true
+		}
+	}
+	return false
+}
+
+// mergeSources returns a copy of the history of base with the one of
+// overlay stacked on top of it.
+func mergeSources(base, overlay map[string][]Source) map[string][]Source {
+	out := subSources(base, "")
+	for k, history := range overlay {
+		out[k] = append(out[k], history...)
+	}
+	return out
+}
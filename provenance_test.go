@@ -0,0 +1,146 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExplain(t *testing.T) {
+	base, err := ParseYamlFile("testdata/default.yml")
+	assert.NoError(t, err)
+	overlay, err := ParseYaml("app:\n  env: prod\n  port: 8080\n")
+	assert.NoError(t, err)
+
+	cfg, err := base.Extend(overlay)
+	assert.NoError(t, err)
+
+	t.Setenv("APP_APP_PORT", "9000")
+	cfg.EnvPrefix("app")
+	cfg.Args("app", "-app-env=staging")
+
+	sources := cfg.Explain("app.port")
+	if assert.Len(t, sources, 2) {
+		assert.Equal(t, Source{Kind: "env", Name: "APP_APP_PORT", Value: 9000}, sources[0])
+		assert.Equal(t, "env APP_APP_PORT", sources[0].String())
+		assert.Equal(t, "file", sources[1].Kind)
+		assert.Equal(t, 8080, sources[1].Value)
+		assert.Equal(t, "file 3:3", sources[1].String())
+	}
+
+	sources = cfg.Explain("app.env")
+	if assert.Len(t, sources, 3) {
+		assert.Equal(t, Source{Kind: "flag", Name: "app-env", Value: "staging"}, sources[0])
+		assert.Equal(t, "prod", sources[1].Value)
+		assert.Equal(t, "testdata/default.yml", sources[2].Name)
+		assert.Equal(t, "file testdata/default.yml:2:3", sources[2].String())
+		assert.Equal(t, "default", sources[2].Value)
+	}
+
+	assert.NoError(t, cfg.Set("app.port", 9001))
+	sources = cfg.Explain("app.port")
+	if assert.Len(t, sources, 3) {
+		assert.Equal(t, Source{Kind: "set", Value: 9001}, sources[0])
+	}
+
+	assert.Nil(t, cfg.Explain("app"))
+	assert.Nil(t, cfg.Explain("app.undefined"))
+}
+
+func TestExplainCarriedOver(t *testing.T) {
+	cfg, err := ParseYamlFile("testdata/default.yml")
+	assert.NoError(t, err)
+
+	sub, err := cfg.Get("app")
+	assert.NoError(t, err)
+	assert.Len(t, sub.Explain("ids.0"), 1)
+
+	cp, err := cfg.Copy()
+	assert.NoError(t, err)
+	assert.NoError(t, cp.Set("app.env", "copy"))
+	assert.Len(t, cp.Explain("app.env"), 2)
+	assert.Len(t, cfg.Explain("app.env"), 1)
+
+	safe := NewSafe(cfg)
+	assert.NoError(t, safe.Set("app.env", "safe"))
+	sources := safe.Explain("app.env")
+	if assert.Len(t, sources, 2) {
+		assert.Equal(t, "safe", sources[0].Value)
+	}
+	assert.Len(t, cfg.Explain("app.env"), 1)
+}
+
+func TestExplainBounded(t *testing.T) {
+	cfg, err := ParseYaml("server:\n  port: 8080\n  hosts: [a, b]\n")
+	assert.NoError(t, err)
+
+	for i := 0; i < 100; i++ {
+		assert.NoError(t, cfg.Set("server.port", i))
+	}
+	sources := cfg.Explain("server.port")
+	if assert.Len(t, sources, 2) {
+		assert.Equal(t, Source{Kind: "set", Value: 99}, sources[0])
+		assert.Equal(t, "file", sources[1].Kind)
+	}
+
+	for i := 0; i < 100; i++ {
+		cfg.Args("app", "-server-port="+strconv.Itoa(i))
+		assert.NoError(t, cfg.Set("server.port", i))
+	}
+	sources = cfg.Explain("server.port")
+	if assert.Len(t, sources, maxSources) {
+		assert.Equal(t, "set", sources[0].Kind)
+		assert.Equal(t, "flag", sources[1].Kind)
+		assert.Equal(t, "file", sources[maxSources-1].Kind)
+	}
+
+	assert.NoError(t, cfg.Set("server", map[string]any{"port": 1}))
+	assert.Nil(t, cfg.Explain("server.port"))
+	assert.Nil(t, cfg.Explain("server.hosts.0"))
+	assert.Len(t, cfg.Explain("server"), 1)
+}
+
+func TestExplainExtendReplaced(t *testing.T) {
+	base, err := ParseYaml("a:\n  b: 1\nc: one\nlist:\n  - host: h1\n    port: 1\n")
+	assert.NoError(t, err)
+	overlay, err := ParseYaml("a: 2\nc: two\nlist:\n  - host: h2\n")
+	assert.NoError(t, err)
+
+	cfg, err := base.Extend(overlay)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, cfg.UInt("a"))
+	assert.Nil(t, cfg.Explain("a.b"))
+	_, ok := cfg.Position("a.b")
+	assert.False(t, ok)
+	pos, _ := cfg.Position("a")
+	assert.Equal(t, "1:1", pos.String())
+	assert.Len(t, cfg.Explain("a"), 1)
+
+	// Replaced keys keep the history they shadow, with the overlay's position.
+	sources := cfg.Explain("c")
+	if assert.Len(t, sources, 2) {
+		assert.Equal(t, "two", sources[0].Value)
+		assert.Equal(t, "one", sources[1].Value)
+	}
+	pos, _ = cfg.Position("c")
+	assert.Equal(t, "2:1", pos.String())
+
+	// List items are replaced as a whole.
+	_, err = cfg.Get("list.0.port")
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.Nil(t, cfg.Explain("list.0.port"))
+	_, ok = cfg.Position("list.0.port")
+	assert.False(t, ok)
+	assert.Len(t, cfg.Explain("list.0.host"), 2)
+
+	unchanged, err := base.Extend(&Config{Root: map[string]any{}})
+	assert.NoError(t, err)
+	assert.Len(t, unchanged.Explain("a.b"), 1)
+	pos, _ = unchanged.Position("a.b")
+	assert.Equal(t, "2:3", pos.String())
+}
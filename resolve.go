@@ -0,0 +1,203 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Resolve expands ${...} references inside string values.
+//
+// A reference is either a dotted path to another value in the configuration
+// or the name of an environment variable. Config paths take precedence over
+// environment variables, and an optional default can be given with the
+// ${NAME:-default} syntax, which is used when the reference is missing or
+// empty. A literal "${" is written as "$${".
+//
+// A string consisting of a single reference takes the type of the referenced
+// value, so "${server.port}" stays an int. References are resolved
+// recursively; a cycle is reported with the full chain of paths involved.
+//
+// Example:
+//
+//	base: https://${HOST:-localhost}:${server.port}
+//	server:
+//	  port: 8443
+//	api: ${base}/api
+func (c *Config) Resolve() error {
+	return c.update(func(root any) (any, error) {
+		r := &resolver{root: root, values: map[string]any{}}
+		return r.resolveValue(root, nil, nil)
+	})
+}
+
+// resolver expands references against a configuration tree, memoizing the
+// resolved value of every path it visits.
+type resolver struct {
+	root   any
+	values map[string]any
+}
+
+// resolve returns the resolved value at the given path. The chain holds the
+// paths currently being resolved and is used to detect cycles.
+func (r *resolver) resolve(parts []string, chain []string) (any, error) {
+	key := ""
+	for _, part := range parts {
+		key = joinPath(key, part)
+	}
+	if v, ok := r.values[key]; ok {
+		// The value is already in the tree: copy it so that maps and
+		// lists aren't shared between paths.
+		return copyValue(v), nil
+	}
+	for _, p := range chain {
+		if p == key {
+			return nil, &PathError{
+				Op:   "resolve",
+				Path: chain[0],
+				Err:  fmt.Errorf("reference cycle: %s", strings.Join(append(chain, key), " -> ")),
+			}
+		}
+	}
+
+	v, err := get("resolve", r.root, key)
+	if err != nil {
+		return nil, err
+	}
+	if v, err = r.resolveValue(v, parts, append(chain, key)); err != nil {
+		return nil, err
+	}
+	r.values[key] = v
+	return v, nil
+}
+
+// resolveValue returns a copy of v with all references expanded.
+func (r *resolver) resolveValue(v any, parts []string, chain []string) (any, error) {
+	switch v := v.(type) {
+	case string:
+		if !strings.Contains(v, "${") {
+			return v, nil
+		}
+		return r.expand(v, chain)
+	case map[string]any:
+		// Visit the keys in order so that errors are reported
+		// deterministically.
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		node := make(map[string]any, len(v))
+		for _, k := range keys {
+			item, err := r.resolve(append(parts[:len(parts):len(parts)], k), chain)
+			if err != nil {
+				return nil, err
+			}
+			node[k] = item
+		}
+		return node, nil
+	case []any:
+		node := make([]any, len(v))
+		for i := range v {
+			item, err := r.resolve(append(parts[:len(parts):len(parts)], strconv.Itoa(i)), chain)
+			if err != nil {
+				return nil, err
+			}
+			node[i] = item
+		}
+		return node, nil
+	}
+	return v, nil
+}
+
+// expand replaces the references in s. The last element of chain is the
+// path s was read from; chain is empty when s is the root.
+func (r *resolver) expand(s string, chain []string) (any, error) {
+	path := chainPath(chain)
+
+	var b strings.Builder
+	for i := 0; i < len(s); {
+		if strings.HasPrefix(s[i:], "$${") {
+			b.WriteString("${")
+			i += 3
+			continue
+		}
+		if !strings.HasPrefix(s[i:], "${") {
+			b.WriteByte(s[i])
+			i++
+			continue
+		}
+
+		end := strings.IndexByte(s[i+2:], '}')
+		if end < 0 {
+			return nil, &PathError{Op: "resolve", Path: path, Err: fmt.Errorf("%w: unterminated reference", ErrInvalidPath)}
+		}
+		expr := s[i+2 : i+2+end]
+		v, err := r.lookup(expr, chain)
+		if err != nil {
+			return nil, err
+		}
+
+		next := i + 2 + end + 1
+		if i == 0 && next == len(s) {
+			// The whole string is a single reference, keep its type.
+			return copyValue(v), nil
+		}
+		str, err := toString(v)
+		if err != nil {
+			return nil, &PathError{Op: "resolve", Path: path, Err: fmt.Errorf("cannot interpolate %q: %w", expr, err)}
+		}
+		b.WriteString(str)
+		i = next
+	}
+	return b.String(), nil
+}
+
+// lookup resolves a single reference expression of the form NAME or
+// NAME:-default.
+func (r *resolver) lookup(expr string, chain []string) (any, error) {
+	name, def, hasDef := strings.Cut(expr, ":-")
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, &PathError{Op: "resolve", Path: chainPath(chain), Err: fmt.Errorf("%w: empty reference", ErrInvalidPath)}
+	}
+
+	if _, err := Get(r.root, name); err == nil {
+		v, err := r.resolve(normalizePath(name), chain)
+		if err != nil {
+			return nil, err
+		}
+		if hasDef && (v == nil || v == "") {
+			return def, nil
+		}
+		return v, nil
+	}
+
+	if v, ok := os.LookupEnv(name); ok && (v != "" || !hasDef) {
+		return v, nil
+	}
+	if hasDef {
+		return def, nil
+	}
+	return nil, &PathError{
+		Op:   "resolve",
+		Path: chainPath(chain),
+		Err:  fmt.Errorf("%w: unresolved reference %q", ErrNotFound, name),
+	}
+}
+
+// chainPath returns the path being resolved, the last element of chain, or
+// "" for a scalar root.
+func chainPath(chain []string) string {
+	if len(chain) == 0 {
+		return ""
+	}
+	return chain[len(chain)-1]
+}
@@ -0,0 +1,124 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolve(t *testing.T) {
+	t.Setenv("RESOLVE_HOST", "example.com")
+	t.Setenv("RESOLVE_EMPTY", "")
+
+	cfg, err := ParseYaml(`
+host: ${RESOLVE_HOST}
+base: https://${host}:${server.port}
+server:
+  port: 8443
+  alias: ${server.port}
+  scheme: ${RESOLVE_SCHEME:-https}
+  empty: ${RESOLVE_EMPTY:-fallback}
+  unset: ${RESOLVE_UNSET:-}
+endpoints:
+  - ${base}/api
+  - ${endpoints.0}/v2
+copy: ${server}
+literal: $${not.a.reference}
+`)
+	assert.NoError(t, err)
+	assert.NoError(t, cfg.Resolve())
+
+	assert.Equal(t, "example.com", cfg.UString("host"))
+	assert.Equal(t, "https://example.com:8443", cfg.UString("base"))
+	assert.Equal(t, 8443, cfg.UInt("server.alias"))
+	assert.Equal(t, "https", cfg.UString("server.scheme"))
+	assert.Equal(t, "fallback", cfg.UString("server.empty"))
+	assert.Equal(t, "", cfg.UString("server.unset", "not empty"))
+	assert.Equal(t, "https://example.com:8443/api", cfg.UString("endpoints.0"))
+	assert.Equal(t, "https://example.com:8443/api/v2", cfg.UString("endpoints.1"))
+	assert.Equal(t, 8443, cfg.UInt("copy.alias"))
+	assert.Equal(t, "${not.a.reference}", cfg.UString("literal"))
+}
+
+func TestResolveSafe(t *testing.T) {
+	cfg := NewSafe(Must(ParseYaml(`
+a: value
+b: ${a}
+`)))
+	assert.NoError(t, cfg.Resolve())
+	assert.Equal(t, "value", cfg.UString("b"))
+}
+
+func TestResolveCopiesReferences(t *testing.T) {
+	cfg, err := ParseYaml(`
+server:
+  port: 8080
+  hosts: [a, b]
+copy: ${server}
+hosts: ${server.hosts}
+again: ${copy}
+`)
+	assert.NoError(t, err)
+	assert.NoError(t, cfg.Resolve())
+
+	assert.NoError(t, cfg.Set("copy.port", 2))
+	assert.NoError(t, cfg.Set("hosts.0", "x"))
+	assert.NoError(t, cfg.Set("again.hosts.1", "y"))
+	assert.Equal(t, 8080, cfg.UInt("server.port"))
+	assert.Equal(t, []any{"a", "b"}, cfg.UList("server.hosts"))
+	assert.Equal(t, 8080, cfg.UInt("again.port"))
+	assert.Equal(t, []any{"a", "b"}, cfg.UList("copy.hosts"))
+}
+
+func TestResolveErrors(t *testing.T) {
+	cfg, err := ParseYaml(`
+a: ${b}
+b: x-${c.d}
+c:
+  d: ${a}
+`)
+	assert.NoError(t, err)
+	err = cfg.Resolve()
+	assert.EqualError(t, err, `resolve "a": reference cycle: a -> b -> c.d -> a`)
+	// The tree is left untouched on failure.
+	assert.Equal(t, "${b}", cfg.UString("a"))
+
+	cfg, err = ParseYaml(`a: ${RESOLVE_UNDEFINED}`)
+	assert.NoError(t, err)
+	err = cfg.Resolve()
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.EqualError(t, err, `resolve "a": key not found: unresolved reference "RESOLVE_UNDEFINED"`)
+
+	cfg, err = ParseYaml(`
+a: prefix ${b}
+b: [1, 2]
+`)
+	assert.NoError(t, err)
+	assert.Error(t, cfg.Resolve())
+
+	cfg, err = ParseYaml(`a: ${b`)
+	assert.NoError(t, err)
+	assert.Error(t, cfg.Resolve())
+}
+
+func TestResolveScalarRoot(t *testing.T) {
+	cfg, err := ParseYaml(`"${RESOLVE_UNDEFINED}"`)
+	assert.NoError(t, err)
+	err = cfg.Resolve()
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.EqualError(t, err, `resolve: key not found: unresolved reference "RESOLVE_UNDEFINED"`)
+
+	cfg, err = ParseYaml(`"${}"`)
+	assert.NoError(t, err)
+	assert.EqualError(t, cfg.Resolve(), `resolve: invalid path: empty reference`)
+
+	t.Setenv("RESOLVE_HOST", "example.com")
+	cfg, err = ParseYaml(`"https://${RESOLVE_HOST}"`)
+	assert.NoError(t, err)
+	assert.NoError(t, cfg.Resolve())
+	assert.Equal(t, "https://example.com", cfg.Root)
+}
@@ -0,0 +1,116 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// safeRoot holds the published snapshot of a thread-safe configuration.
+type safeRoot struct {
+	mu      sync.Mutex // serializes writers and guards lastErr
+	root    atomic.Pointer[any]
+	lastErr error
+}
+
+// NewSafe returns a configuration that can be read and written from
+// multiple goroutines.
+//
+// The returned config holds a deep copy of cfg's values. Getters read from
+// an immutable snapshot, while Set(), Env(), EnvPrefix(), Flag() and Args()
+// apply their changes to a private copy and publish it atomically, so
+// readers never observe a partially applied update.
+//
+// Maps and lists passed to Set() are copied, and Get(), Copy() and Extend()
+// return thread-safe configs as well.
+//
+// The Root field of a thread-safe config is not used; call Snapshot() to
+// access the current tree. Values returned by Map(), List() and Snapshot()
+// are shared with other readers and must not be modified.
+//
+// Example:
+//
+//	var cfg = config.NewSafe(config.Must(config.ParseYamlFile("config.yml")))
+func NewSafe(cfg *Config) *Config {
+	c := newSafe(copyValue(cfg.root()))
+	c.pos, c.sources = cfg.positions(), cfg.sourcesBelow("")
+	return c
+}
+
+// newSafe returns a thread-safe config publishing root as its snapshot.
+func newSafe(root any) *Config {
+	c := &Config{safe: &safeRoot{}}
+	c.safe.root.Store(&root)
+	return c
+}
+
+// Snapshot returns the current configuration tree. For configs created by
+// NewSafe it is an immutable snapshot; otherwise it is the Root field.
+func (c *Config) Snapshot() any {
+	return c.root()
+}
+
+// root returns the tree getters read from.
+func (c *Config) root() any {
+	if c.safe != nil {
+		return *c.safe.root.Load()
+	}
+	return c.Root
+}
+
+// update applies fn to the configuration tree and stores the tree it
+// returns. Configs created by NewSafe pass fn a deep copy of the current
+// snapshot and publish the result only if fn succeeds.
+func (c *Config) update(fn func(root any) (any, error)) error {
+	if c.safe == nil {
+		root, err := fn(c.Root)
+		if err != nil {
+			return err
+		}
+		c.Root = root
+		return nil
+	}
+
+	c.safe.mu.Lock()
+	defer c.safe.mu.Unlock()
+
+	root, err := fn(copyValue(*c.safe.root.Load()))
+	if err != nil {
+		return err
+	}
+	c.safe.root.Store(&root)
+	return nil
+}
+
+// setError records the error returned by Error().
+func (c *Config) setError(err error) {
+	if c.safe == nil {
+		c.lastErr = err
+		return
+	}
+	c.safe.mu.Lock()
+	defer c.safe.mu.Unlock()
+	c.safe.lastErr = err
+}
+
+// copyValue returns a deep copy of a configuration tree.
+func copyValue(value any) any {
+	switch value := value.(type) {
+	case map[string]any:
+		node := make(map[string]any, len(value))
+		for k, v := range value {
+			node[k] = copyValue(v)
+		}
+		return node
+	case []any:
+		node := make([]any, len(value))
+		for i, v := range value {
+			node[i] = copyValue(v)
+		}
+		return node
+	}
+	return value
+}
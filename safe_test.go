@@ -0,0 +1,114 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSafeConfig(t *testing.T) {
+	cfg := NewSafe(Must(ParseYaml(yamlString)))
+	testConfig(t, cfg)
+
+	assert.NoError(t, cfg.Set("map.key8", "changed"))
+	assert.Equal(t, "changed", cfg.UString("map.key8"))
+	assert.Error(t, cfg.Set("map.key0.foo", "bar"))
+
+	sub, err := cfg.Get("config")
+	assert.NoError(t, err)
+	assert.NoError(t, sub.Set("server.0", "www.golang.org"))
+	assert.Equal(t, "www.golang.org", sub.UString("server.0"))
+	assert.Equal(t, "www.google.com", cfg.UString("config.server.0"))
+
+	copied, err := cfg.Copy()
+	assert.NoError(t, err)
+	assert.Equal(t, "changed", copied.UString("map.key8"))
+}
+
+func TestSafeConfigIsolation(t *testing.T) {
+	orig := Must(ParseYaml(yamlString))
+	cfg := NewSafe(orig)
+
+	snapshot := cfg.Snapshot()
+	assert.NoError(t, cfg.Set("map.key8", "changed"))
+	value, err := Get(snapshot, "map.key8")
+	assert.NoError(t, err)
+	assert.Equal(t, "value8", value)
+
+	_ = orig.Set("map.key6", 0)
+	assert.Equal(t, 42, cfg.UInt("map.key6"))
+}
+
+func TestSafeConfigSetCopies(t *testing.T) {
+	cfg := NewSafe(Must(ParseYaml(yamlString)))
+
+	value := map[string]any{"ids": []any{1, 2}}
+	assert.NoError(t, cfg.Set("map.new", value))
+	value["ids"].([]any)[0] = 3
+	value["other"] = true
+	assert.Equal(t, 1, cfg.UInt("map.new.ids.0"))
+	assert.Equal(t, false, cfg.UBool("map.new.other"))
+}
+
+func TestSafeConfigCopyIsSafe(t *testing.T) {
+	cfg := NewSafe(Must(ParseYaml(yamlString)))
+
+	copied, err := cfg.Copy()
+	assert.NoError(t, err)
+	assert.NotNil(t, copied.safe)
+	assert.Nil(t, copied.Root)
+
+	extended, err := cfg.Extend(Must(ParseYaml("map:\n  key8: extended\n")))
+	assert.NoError(t, err)
+	assert.NotNil(t, extended.safe)
+	assert.Equal(t, "extended", extended.UString("map.key8"))
+	assert.Equal(t, "value8", cfg.UString("map.key8"))
+
+	plain, err := Must(ParseYaml(yamlString)).Copy()
+	assert.NoError(t, err)
+	assert.Nil(t, plain.safe)
+}
+
+func TestSafeConfigRace(t *testing.T) {
+	cfg := NewSafe(Must(ParseYaml(yamlString)))
+	t.Setenv("SAFE_MAP_KEY8", "from env")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 200; j++ {
+				_ = cfg.UString("map.key8")
+				_ = cfg.UInt("map.key6")
+				_ = cfg.UMap("config.admin.0")
+				_ = cfg.Error()
+				if sub, err := cfg.Get("list"); err == nil {
+					_ = sub.UBool("0")
+				}
+			}
+		}()
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for j := 0; j < 200; j++ {
+			_ = cfg.Set("map.key6", j)
+			_ = cfg.Set(fmt.Sprintf("extra.key%d", j), j)
+			cfg.EnvPrefix("safe")
+			cfg.Args("app", "-map-key7=7")
+		}
+	}()
+	wg.Wait()
+
+	assert.Equal(t, 199, cfg.UInt("map.key6"))
+	assert.Equal(t, "from env", cfg.UString("map.key8"))
+	assert.Equal(t, "7", cfg.UString("map.key7"))
+	assert.Equal(t, 199, cfg.UInt("extra.key199"))
+}
@@ -0,0 +1,341 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Schema describes the expected shape of a configuration.
+//
+// Schemas can be declared in Go or loaded from a JSON Schema document with
+// ParseJsonSchema(). Only the subset of JSON Schema listed below is
+// supported; additionalProperties must be a boolean.
+//
+// Types are checked with the same conversion rules as the getters, so an
+// "integer" accepts the string "9000" set from an environment variable just
+// like Int() does.
+type Schema struct {
+	// Type is one of "object", "array", "string", "integer", "number",
+	// "boolean" or "null". An empty type accepts any value. Types lists
+	// the alternatives of a union type, such as ["string", "null"], which
+	// JSON Schema documents write as an array.
+	Type        string   `json:"-"`
+	Types       []string `json:"-"`
+	Description string   `json:"description,omitempty"`
+
+	// Properties, Required and AdditionalProperties apply to objects.
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *bool              `json:"additionalProperties,omitempty"`
+
+	// Items, MinItems and MaxItems apply to arrays.
+	Items    *Schema `json:"items,omitempty"`
+	MinItems *int    `json:"minItems,omitempty"`
+	MaxItems *int    `json:"maxItems,omitempty"`
+
+	// Minimum and Maximum apply to numbers.
+	Minimum *float64 `json:"minimum,omitempty"`
+	Maximum *float64 `json:"maximum,omitempty"`
+
+	// Pattern, MinLength and MaxLength apply to strings.
+	Pattern   string `json:"pattern,omitempty"`
+	MinLength *int   `json:"minLength,omitempty"`
+	MaxLength *int   `json:"maxLength,omitempty"`
+
+	// Enum lists the allowed values.
+	Enum []any `json:"enum,omitempty"`
+}
+
+// schemaFields is Schema without its JSON methods, to decode and encode
+// the other fields.
+type schemaFields Schema
+
+// UnmarshalJSON implements json.Unmarshaler, reading "type" as a string or
+// a list of strings.
+func (s *Schema) UnmarshalJSON(data []byte) error {
+	var doc struct {
+		*schemaFields
+		Type json.RawMessage `json:"type"`
+	}
+	doc.schemaFields = (*schemaFields)(s)
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return err
+	}
+	s.Type, s.Types = "", nil
+	if len(doc.Type) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(doc.Type, &s.Type); err == nil {
+		return nil
+	}
+	if err := json.Unmarshal(doc.Type, &s.Types); err != nil {
+		return fmt.Errorf("%w: schema type must be a string or a list of strings: %s", ErrTypeMismatch, doc.Type)
+	}
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler, writing Type, or Types as a list.
+func (s *Schema) MarshalJSON() ([]byte, error) {
+	doc := struct {
+		*schemaFields
+		Type any `json:"type,omitempty"`
+	}{schemaFields: (*schemaFields)(s)}
+	switch {
+	case len(s.Types) > 0:
+		doc.Type = s.Types
+	case s.Type != "":
+		doc.Type = s.Type
+	}
+	return json.Marshal(doc)
+}
+
+// ParseJsonSchema parses a JSON Schema document from the given string.
+func ParseJsonSchema(schema string) (*Schema, error) {
+	return parseJsonSchema([]byte(schema))
+}
+
+// ParseJsonSchemaFile reads a JSON Schema document from the given filename.
+func ParseJsonSchemaFile(filename string) (*Schema, error) {
+	schema, err := os.ReadFile(filepath.Clean(filename))
+	if err != nil {
+		return nil, err
+	}
+	return parseJsonSchema(schema)
+}
+
+// parseJsonSchema performs the real JSON Schema parsing.
+func parseJsonSchema(schema []byte) (*Schema, error) {
+	var s Schema
+	if err := json.Unmarshal(schema, &s); err != nil {
+		return nil, &PathError{Op: "parse", Err: err}
+	}
+	return &s, nil
+}
+
+// ValidationError reports every violation found by Validate().
+type ValidationError struct {
+	Errors []*PathError
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// Unwrap returns the individual violations, so that errors.Is can match the
+// sentinel errors they wrap.
+func (e *ValidationError) Unwrap() []error {
+	errs := make([]error, len(e.Errors))
+	for i, err := range e.Errors {
+		errs[i] = err
+	}
+	return errs
+}
+
+// Validate checks the configuration against the given schema and reports
+// all violations at once as a *ValidationError, or returns nil.
+//
+// Each violation is a *PathError naming the offending dotted path and
+// wrapping ErrNotFound for missing required keys, ErrTypeMismatch for wrong
+// types, ErrOutOfRange for values outside their bounds or enum,
+// ErrPatternMismatch for strings not matching their pattern and
+// ErrUnknownKey for keys not allowed by additionalProperties.
+//
+// Example:
+//
+//	schema, err := config.ParseJsonSchemaFile("schema.json")
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	if err := cfg.Validate(schema); err != nil {
+//	    log.Fatal(err) // lists every violation, one per line
+//	}
+func (c *Config) Validate(s *Schema) error {
+	v := validator{c: c}
+	v.validate(c.root(), s, "")
+	if len(v.errs) == 0 {
+		return nil
+	}
+	return &ValidationError{Errors: v.errs}
+}
+
+// validator collects the violations found while walking a configuration.
+type validator struct {
+	c    *Config
+	errs []*PathError
+}
+
+// report records a violation at path.
+func (v *validator) report(path string, err error) {
+	v.errs = append(v.errs, v.c.pathError("validate", path, err))
+}
+
+// validate checks value, found at path, against s.
+func (v *validator) validate(value any, s *Schema, path string) {
+	if s == nil {
+		return
+	}
+	if !v.checkType(value, s, path) {
+		return
+	}
+
+	if len(s.Enum) > 0 && !inEnum(value, s.Enum) {
+		v.report(path, fmt.Errorf("%w: %v is not one of %v", ErrOutOfRange, value, s.Enum))
+	}
+
+	if s.Minimum != nil || s.Maximum != nil {
+		if f, err := toFloat64(value); err == nil {
+			if s.Minimum != nil && f < *s.Minimum {
+				v.report(path, fmt.Errorf("%w: %v is less than %v", ErrOutOfRange, value, *s.Minimum))
+			}
+			if s.Maximum != nil && f > *s.Maximum {
+				v.report(path, fmt.Errorf("%w: %v is greater than %v", ErrOutOfRange, value, *s.Maximum))
+			}
+		}
+	}
+
+	if str, ok := value.(string); ok {
+		v.validateString(str, s, path)
+	}
+
+	switch value := value.(type) {
+	case map[string]any:
+		v.validateObject(value, s, path)
+	case []any:
+		v.validateArray(value, s, path)
+	}
+}
+
+// checkType reports a violation if value doesn't match the type of s, or
+// any of its union types.
+func (v *validator) checkType(value any, s *Schema, path string) bool {
+	types := s.Types
+	if s.Type != "" {
+		types = append([]string{s.Type}, types...)
+	}
+	if len(types) == 0 {
+		return true
+	}
+	var err error
+	for _, typ := range types {
+		if err = matchType(value, typ); err == nil {
+			return true
+		}
+	}
+	if len(types) > 1 {
+		err = typeMismatch(strings.Join(types, " or "), value)
+	}
+	v.report(path, err)
+	return false
+}
+
+// matchType returns an error if value doesn't match typ.
+func matchType(value any, typ string) error {
+	var err error
+	switch typ {
+	case "":
+	case "object":
+		if _, ok := value.(map[string]any); !ok {
+			err = typeMismatch("object", value)
+		}
+	case "array":
+		if _, ok := value.([]any); !ok {
+			err = typeMismatch("array", value)
+		}
+	case "string":
+		_, err = toString(value)
+	case "integer":
+		_, err = toInt(value)
+	case "number":
+		_, err = toFloat64(value)
+	case "boolean":
+		_, err = toBool(value)
+	case "null":
+		if value != nil {
+			err = typeMismatch("null", value)
+		}
+	default:
+		err = fmt.Errorf("%w: unknown schema type %q", ErrTypeMismatch, typ)
+	}
+	return err
+}
+
+// validateString checks the string constraints of s.
+func (v *validator) validateString(str string, s *Schema, path string) {
+	length := utf8.RuneCountInString(str)
+	if s.MinLength != nil && length < *s.MinLength {
+		v.report(path, fmt.Errorf("%w: length %d is less than %d", ErrOutOfRange, length, *s.MinLength))
+	}
+	if s.MaxLength != nil && length > *s.MaxLength {
+		v.report(path, fmt.Errorf("%w: length %d is greater than %d", ErrOutOfRange, length, *s.MaxLength))
+	}
+	if s.Pattern != "" {
+		re, err := regexp.Compile(s.Pattern)
+		if err != nil {
+			v.report(path, fmt.Errorf("invalid schema pattern: %w", err))
+		} else if !re.MatchString(str) {
+			v.report(path, fmt.Errorf("%w: %q does not match %q", ErrPatternMismatch, str, s.Pattern))
+		}
+	}
+}
+
+// validateObject checks the required keys and properties of a map.
+func (v *validator) validateObject(m map[string]any, s *Schema, path string) {
+	for _, key := range s.Required {
+		if _, ok := m[key]; !ok {
+			v.report(joinPath(path, key), ErrNotFound)
+		}
+	}
+
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		if prop, ok := s.Properties[k]; ok {
+			v.validate(m[k], prop, joinPath(path, k))
+		} else if s.AdditionalProperties != nil && !*s.AdditionalProperties {
+			v.report(joinPath(path, k), ErrUnknownKey)
+		}
+	}
+}
+
+// validateArray checks the length and the items of a list.
+func (v *validator) validateArray(list []any, s *Schema, path string) {
+	if s.MinItems != nil && len(list) < *s.MinItems {
+		v.report(path, fmt.Errorf("%w: %d items, expected at least %d", ErrOutOfRange, len(list), *s.MinItems))
+	}
+	if s.MaxItems != nil && len(list) > *s.MaxItems {
+		v.report(path, fmt.Errorf("%w: %d items, expected at most %d", ErrOutOfRange, len(list), *s.MaxItems))
+	}
+	for i, item := range list {
+		v.validate(item, s.Items, joinPath(path, strconv.Itoa(i)))
+	}
+}
+
+// inEnum reports whether value equals one of the allowed values. Values are
+// compared by their string form, so 8080, 8080.0 and "8080" are equal.
+func inEnum(value any, enum []any) bool {
+	for _, e := range enum {
+		if fmt.Sprint(e) == fmt.Sprint(value) {
+			return true
+		}
+	}
+	return false
+}
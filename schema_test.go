@@ -0,0 +1,170 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidate(t *testing.T) {
+	schema, err := ParseJsonSchemaFile("testdata/schema.json")
+	require.NoError(t, err)
+
+	cfg, err := ParseYaml(`
+server:
+  host: localhost
+  port: 8080
+  debug: false
+  mode: dev
+database:
+  hosts: [primary.db.com, replica.db.com]
+  timeout: 2.5
+`)
+	require.NoError(t, err)
+	assert.NoError(t, cfg.Validate(schema))
+
+	// Values set from the environment are strings.
+	_ = cfg.Set("server.port", "9000")
+	_ = cfg.Set("server.debug", "true")
+	assert.NoError(t, cfg.Validate(schema))
+}
+
+func TestValidateAggregatesErrors(t *testing.T) {
+	schema, err := ParseJsonSchemaFile("testdata/schema.json")
+	require.NoError(t, err)
+
+	cfg, err := ParseYaml(`
+server:
+  host: Local_Host
+  port: 70000
+  debug: maybe
+  mode: staging
+database:
+  hosts: [db, replica.db.com, 42]
+  timeout: soon
+logging:
+  level: debug
+`)
+	require.NoError(t, err)
+
+	err = cfg.Validate(schema)
+	var validationErr *ValidationError
+	require.ErrorAs(t, err, &validationErr)
+
+	var got []string
+	for _, e := range validationErr.Errors {
+		assert.Equal(t, "validate", e.Op)
+		got = append(got, e.Path)
+	}
+	assert.Equal(t, []string{
+		"database.hosts.0",
+		"database.timeout",
+		"logging",
+		"server.debug",
+		"server.host",
+		"server.mode",
+		"server.port",
+	}, got)
+
+	assert.ErrorIs(t, err, ErrOutOfRange)
+	assert.ErrorIs(t, err, ErrTypeMismatch)
+	assert.ErrorIs(t, err, ErrPatternMismatch)
+	assert.ErrorIs(t, err, ErrUnknownKey)
+	assert.False(t, errors.Is(err, ErrNotFound))
+	assert.Contains(t, err.Error(), `validate "server.port": value out of range: 70000 is greater than 65535`)
+
+	cfg, err = ParseYaml(`
+server:
+  host: localhost
+`)
+	require.NoError(t, err)
+	err = cfg.Validate(schema)
+	require.ErrorAs(t, err, &validationErr)
+	assert.Len(t, validationErr.Errors, 2)
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.EqualError(t, err, "validate \"database\": key not found\nvalidate \"server.port\": key not found")
+}
+
+func TestValidateGoSchema(t *testing.T) {
+	minItems, maxLength := 2, 5
+	schema := &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"config": {
+				Type: "object",
+				Properties: map[string]*Schema{
+					"server": {Type: "array", MinItems: &minItems, Items: &Schema{Pattern: `^www\.`}},
+					"admin": {
+						Type: "array",
+						Items: &Schema{
+							Type:     "object",
+							Required: []string{"username", "password"},
+							Properties: map[string]*Schema{
+								"password": {Type: "string", MaxLength: &maxLength},
+							},
+						},
+					},
+				},
+			},
+			"map": {Type: "object", Properties: map[string]*Schema{"key0": {Type: "integer"}}},
+		},
+	}
+
+	cfg, err := ParseYaml(yamlString)
+	require.NoError(t, err)
+
+	err = cfg.Validate(schema)
+	var validationErr *ValidationError
+	require.ErrorAs(t, err, &validationErr)
+	require.Len(t, validationErr.Errors, 1)
+	assert.Equal(t, "map.key0", validationErr.Errors[0].Path)
+	assert.ErrorIs(t, validationErr.Errors[0], ErrTypeMismatch)
+
+	_, err = ParseJsonSchema(`{"type": 1}`)
+	assert.Error(t, err)
+	_, err = ParseJsonSchema(`{"properties": {"a": {"type": {"x": 1}}}}`)
+	assert.ErrorIs(t, err, ErrTypeMismatch)
+	assert.ErrorContains(t, err, `schema type must be a string or a list of strings: {"x": 1}`)
+}
+
+func TestSchemaUnionType(t *testing.T) {
+	schema, err := ParseJsonSchema(`{
+		"type": "object",
+		"properties": {
+			"name": {"type": ["string", "null"], "description": "optional name"},
+			"port": {"type": ["integer", "null"], "minimum": 1},
+			"tags": {"type": ["array"]}
+		}
+	}`)
+	require.NoError(t, err)
+	assert.Equal(t, "object", schema.Type)
+	assert.Equal(t, []string{"string", "null"}, schema.Properties["name"].Types)
+	assert.Equal(t, "optional name", schema.Properties["name"].Description)
+
+	cfg, err := ParseYaml("name: ~\nport: 8080\ntags: [a]\n")
+	require.NoError(t, err)
+	assert.NoError(t, cfg.Validate(schema))
+
+	cfg, err = ParseYaml("port: [1]\ntags: x\n")
+	require.NoError(t, err)
+	err = cfg.Validate(schema)
+	assert.ErrorIs(t, err, ErrTypeMismatch)
+	assert.EqualError(t, err, `1:1: validate "port": type mismatch: expected integer or null; got []interface {}
+2:1: validate "tags": type mismatch: expected array; got string`)
+
+	out, err := json.Marshal(schema.Properties["port"])
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"type": ["integer", "null"], "minimum": 1}`, string(out))
+	out, err = json.Marshal(schema)
+	require.NoError(t, err)
+	again, err := ParseJsonSchema(string(out))
+	require.NoError(t, err)
+	assert.Equal(t, schema, again)
+}
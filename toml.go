@@ -0,0 +1,148 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"time"
+
+	"github.com/BurntSushi/toml"
+)
+
+// ParseTomlBytes parses a TOML configuration from the given byte slice.
+//
+// The contents of the byte slice should be a valid TOML document. The
+// function will return an error if the TOML is invalid.
+//
+// Tables and arrays of tables become maps and lists, integers become int and
+// datetimes become strings in the format they were written in, so they can be
+// read back with String().
+func ParseTomlBytes(cfg []byte) (*Config, error) {
+	return parseToml(cfg, "")
+}
+
+// ParseToml parses a TOML configuration from the given string.
+//
+// The contents of the string should be a valid TOML document. The function
+// will return an error if the TOML is invalid.
+//
+// The resulting configuration is returned as a *Config, which can be used
+// to access the configuration values.
+func ParseToml(cfg string) (*Config, error) {
+	return parseToml([]byte(cfg), "")
+}
+
+// ParseTomlFile reads a TOML configuration from the given filename.
+//
+// The contents of the file should be a valid TOML document. The function
+// will return an error if the TOML is invalid.
+//
+// The resulting configuration is returned as a *Config, which can be used
+// to access the configuration values.
+func ParseTomlFile(filename string) (*Config, error) {
+	cfg, err := os.ReadFile(filepath.Clean(filename))
+	if err != nil {
+		return nil, err
+	}
+	return parseToml(cfg, filename)
+}
+
+// RenderToml marshals the given configuration into a TOML formatted string.
+//
+// The top level of the configuration must be a map. Nested maps are rendered
+// as tables and lists of maps as arrays of tables. If the configuration cannot
+// be marshaled, the function returns an error.
+func RenderToml(cfg any) (string, error) {
+	if v := reflect.Indirect(reflect.ValueOf(cfg)); v.Kind() != reflect.Map && v.Kind() != reflect.Struct {
+		return "", typeMismatch("map or struct", cfg)
+	}
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(cfg); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// parseToml performs the real TOML parsing.
+func parseToml(cfg []byte, filename string) (*Config, error) {
+	var out any
+	var err error
+	if err = toml.Unmarshal(cfg, &out); err != nil {
+		return nil, &PathError{Op: "parse", Err: err}
+	}
+	if out, err = normalizeToml(out); err != nil {
+		return nil, err
+	}
+	if out, err = normalizeValue(out); err != nil {
+		return nil, err
+	}
+	return newParsed(out, filename, nil), nil
+}
+
+// normalizeToml converts the types produced by the TOML decoder that
+// normalizeValue doesn't know about: int64 integers, []map[string]any arrays
+// of tables and time.Time datetimes.
+func normalizeToml(value any) (any, error) {
+	switch value := value.(type) {
+	case map[string]any:
+		for k, v := range value {
+			item, err := normalizeToml(v)
+			if err != nil {
+				return nil, err
+			}
+			value[k] = item
+		}
+		return value, nil
+	case []map[string]any:
+		node := make([]any, len(value))
+		for i, v := range value {
+			item, err := normalizeToml(v)
+			if err != nil {
+				return nil, err
+			}
+			node[i] = item
+		}
+		return node, nil
+	case []any:
+		for i, v := range value {
+			item, err := normalizeToml(v)
+			if err != nil {
+				return nil, err
+			}
+			value[i] = item
+		}
+		return value, nil
+	case int64:
+		if value < math.MinInt || value > math.MaxInt {
+			return nil, &PathError{
+				Op:  "parse",
+				Err: fmt.Errorf("%w: integer out of range: %d", ErrTypeMismatch, value),
+			}
+		}
+		return int(value), nil
+	case time.Time:
+		return formatTomlTime(value), nil
+	}
+	return value, nil
+}
+
+// formatTomlTime renders a TOML datetime back to the layout it was written
+// in. The decoder marks local dates and times with dedicated locations.
+func formatTomlTime(t time.Time) string {
+	switch t.Location().String() {
+	case "datetime-local":
+		return t.Format("2006-01-02T15:04:05.999999999")
+	case "date-local":
+		return t.Format(time.DateOnly)
+	case "time-local":
+		return t.Format("15:04:05.999999999")
+	}
+	return t.Format(time.RFC3339Nano)
+}
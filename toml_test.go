@@ -0,0 +1,47 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTomlConfig(t *testing.T) {
+	cfg, err := ParseYaml(yamlString)
+	assert.NoError(t, err)
+	str, err := RenderToml(cfg.Root)
+	assert.NoError(t, err)
+	cfg, err = ParseToml(str)
+	assert.NoError(t, err)
+	testConfig(t, cfg)
+}
+
+func TestParseTomlFile(t *testing.T) {
+	cfg, err := ParseTomlFile("testdata/default.toml")
+	assert.NoError(t, err)
+
+	assert.Equal(t, "example", cfg.UString("title"))
+	assert.Equal(t, "localhost", cfg.UString("server.host"))
+	assert.Equal(t, 8080, cfg.UInt("server.port"))
+	assert.Equal(t, 0.5, cfg.UFloat64("server.ratio"))
+	assert.False(t, cfg.UBool("server.debug", true))
+	assert.Equal(t, "1979-05-27T07:32:00Z", cfg.UString("server.started"))
+	assert.Equal(t, "1979-05-27", cfg.UString("server.date"))
+	assert.Equal(t, "hobbes", cfg.UString("admin.1.username"))
+	assert.Equal(t, "replica.db.com", cfg.UString("database.hosts.1"))
+
+	_, err = ParseTomlFile("testdata/missing.toml")
+	assert.Error(t, err)
+}
+
+func TestParseTomlError(t *testing.T) {
+	_, err := ParseTomlBytes([]byte("key = "))
+	assert.Error(t, err)
+
+	_, err = RenderToml([]any{1, 2})
+	assert.Error(t, err)
+}
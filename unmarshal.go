@@ -0,0 +1,333 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Unmarshal decodes the value at the given dotted path into out, which must
+// be a non-nil pointer. An empty path decodes the whole configuration.
+//
+// Struct fields are matched against map keys using the `config` struct tag,
+// falling back to a case-insensitive match on the field name. A tag of "-"
+// skips the field, and embedded structs without a tag are flattened into
+// their parent. Fields with no matching key are left untouched.
+//
+// Scalar values are converted with the same rules as Bool(), Int(),
+// Float64() and String(), so strings coming from Env() or Args() can be
+// decoded into numeric and boolean fields. time.Duration and time.Time
+// fields follow the rules of Duration() and Time(), and scalar values are
+// passed as text to types implementing encoding.TextUnmarshaler. Nested
+// structs, slices, arrays, maps with string keys, pointers and interfaces
+// are supported.
+//
+// Example:
+//
+//	type Server struct {
+//	    Host string `config:"host"`
+//	    Port int    `config:"port"`
+//	}
+//
+//	var srv Server
+//	err := cfg.Unmarshal("server", &srv)
+func (c *Config) Unmarshal(path string, out any) error {
+	v := reflect.ValueOf(out)
+	if v.Kind() != reflect.Pointer || v.IsNil() {
+		return &PathError{Op: "unmarshal", Path: path, Err: typeMismatch("non-nil pointer", out)}
+	}
+	return c.decode("unmarshal", path, v.Elem())
+}
+
+// decode stores the value at path into v, reporting errors as op.
+func (c *Config) decode(op, path string, v reflect.Value) error {
+	n, err := get(op, c.root(), path)
+	if err != nil {
+		return err
+	}
+	d := decoder{c: c, op: op}
+	return d.value(n, v, canonicalPath(path))
+}
+
+// normalizePath splits a dotted path into its parts, dropping a leading
+// empty part the same way Get() does.
+func normalizePath(path string) []string {
+	parts := splitKeyOnParts(path)
+	if len(parts) > 0 && parts[0] == "" {
+		parts = parts[1:]
+	}
+	return parts
+}
+
+// joinPath appends key to a dotted base path, using bracket notation for
+// keys that contain dots.
+func joinPath(base, key string) string {
+	if strings.Contains(key, ".") {
+		key = "[" + key + "]"
+	}
+	if base == "" {
+		return key
+	}
+	return base + "." + key
+}
+
+// decoder converts config values into Go values, reporting errors as op.
+type decoder struct {
+	c  *Config
+	op string
+}
+
+// error returns an error for a value that can't be decoded into typ.
+// The err argument must wrap ErrTypeMismatch.
+func (d decoder) error(path string, typ reflect.Type, err error) error {
+	return d.c.pathError(d.op, path, fmt.Errorf("cannot decode into %s: %w", typ, err))
+}
+
+var (
+	durationType        = reflect.TypeOf(time.Duration(0))
+	timeType            = reflect.TypeOf(time.Time{})
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// value stores the config value n into v, converting as needed.
+func (d decoder) value(n any, v reflect.Value, path string) error {
+	if n == nil {
+		v.Set(reflect.Zero(v.Type()))
+		return nil
+	}
+
+	switch v.Type() {
+	case durationType:
+		dur, err := toDuration(n)
+		if err != nil {
+			return d.error(path, v.Type(), err)
+		}
+		v.SetInt(int64(dur))
+		return nil
+	case timeType:
+		t, err := toTime(n)
+		if err != nil {
+			return d.error(path, v.Type(), err)
+		}
+		v.Set(reflect.ValueOf(t))
+		return nil
+	}
+
+	if v.CanAddr() && v.Addr().Type().Implements(textUnmarshalerType) {
+		if s, err := toString(n); err == nil {
+			if err := v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s)); err != nil {
+				return d.error(path, v.Type(), fmt.Errorf("%w: %w", ErrTypeMismatch, err))
+			}
+			return nil
+		}
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return d.value(n, v.Elem(), path)
+	case reflect.Interface:
+		if v.NumMethod() != 0 {
+			return d.error(path, v.Type(), fmt.Errorf("%w: non-empty interface", ErrTypeMismatch))
+		}
+		// Copy maps and lists so the struct doesn't share the config tree.
+		v.Set(reflect.ValueOf(copyValue(n)))
+		return nil
+	case reflect.Bool:
+		b, err := toBool(n)
+		if err != nil {
+			return d.error(path, v.Type(), err)
+		}
+		v.SetBool(b)
+		return nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := toInt(n)
+		if err != nil {
+			return d.error(path, v.Type(), err)
+		}
+		if v.OverflowInt(int64(i)) {
+			return d.error(path, v.Type(), fmt.Errorf("%w: value %d overflows", ErrTypeMismatch, i))
+		}
+		v.SetInt(int64(i))
+		return nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		i, err := toInt(n)
+		if err != nil {
+			return d.error(path, v.Type(), err)
+		}
+		if i < 0 || v.OverflowUint(uint64(i)) {
+			return d.error(path, v.Type(), fmt.Errorf("%w: value %d overflows", ErrTypeMismatch, i))
+		}
+		v.SetUint(uint64(i))
+		return nil
+	case reflect.Float32, reflect.Float64:
+		f, err := toFloat64(n)
+		if err != nil {
+			return d.error(path, v.Type(), err)
+		}
+		if v.OverflowFloat(f) {
+			return d.error(path, v.Type(), fmt.Errorf("%w: value %v overflows", ErrTypeMismatch, f))
+		}
+		v.SetFloat(f)
+		return nil
+	case reflect.String:
+		s, err := toString(n)
+		if err != nil {
+			return d.error(path, v.Type(), err)
+		}
+		v.SetString(s)
+		return nil
+	case reflect.Slice:
+		list, ok := n.([]any)
+		if !ok {
+			return d.error(path, v.Type(), typeMismatch("[]any", n))
+		}
+		slice := reflect.MakeSlice(v.Type(), len(list), len(list))
+		for i, item := range list {
+			if err := d.value(item, slice.Index(i), joinPath(path, strconv.Itoa(i))); err != nil {
+				return err
+			}
+		}
+		v.Set(slice)
+		return nil
+	case reflect.Array:
+		list, ok := n.([]any)
+		if !ok {
+			return d.error(path, v.Type(), typeMismatch("[]any", n))
+		}
+		if len(list) > v.Len() {
+			return d.error(path, v.Type(),
+				fmt.Errorf("%w: list has %d items; array holds only %d", ErrTypeMismatch, len(list), v.Len()))
+		}
+		for i := 0; i < v.Len(); i++ {
+			if i < len(list) {
+				if err := d.value(list[i], v.Index(i), joinPath(path, strconv.Itoa(i))); err != nil {
+					return err
+				}
+			} else {
+				v.Index(i).Set(reflect.Zero(v.Type().Elem()))
+			}
+		}
+		return nil
+	case reflect.Map:
+		return d.mapValue(n, v, path)
+	case reflect.Struct:
+		return d.structValue(n, v, path)
+	}
+	return d.error(path, v.Type(), fmt.Errorf("%w: unsupported type", ErrTypeMismatch))
+}
+
+// mapValue stores a map[string]any config value into a map with string keys.
+func (d decoder) mapValue(n any, v reflect.Value, path string) error {
+	if v.Type().Key().Kind() != reflect.String {
+		return d.error(path, v.Type(), fmt.Errorf("%w: map key must be a string", ErrTypeMismatch))
+	}
+	m, ok := n.(map[string]any)
+	if !ok {
+		return d.error(path, v.Type(), typeMismatch("map[string]any", n))
+	}
+	if v.IsNil() {
+		v.Set(reflect.MakeMapWithSize(v.Type(), len(m)))
+	}
+
+	// Sort the keys so that errors are reported deterministically.
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	elemType := v.Type().Elem()
+	for _, k := range keys {
+		elem := reflect.New(elemType).Elem()
+		if err := d.value(m[k], elem, joinPath(path, k)); err != nil {
+			return err
+		}
+		v.SetMapIndex(reflect.ValueOf(k).Convert(v.Type().Key()), elem)
+	}
+	return nil
+}
+
+// structValue stores a map[string]any config value into the fields of a
+// struct.
+func (d decoder) structValue(n any, v reflect.Value, path string) error {
+	m, ok := n.(map[string]any)
+	if !ok {
+		return d.error(path, v.Type(), typeMismatch("map[string]any", n))
+	}
+
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("config")
+		if tag == "-" {
+			continue
+		}
+
+		// Embedded structs without a tag share their parent's keys.
+		if field.Anonymous && tag == "" {
+			ft := field.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				fv := v.Field(i)
+				if fv.Kind() == reflect.Pointer {
+					if !fv.CanSet() {
+						continue
+					}
+					if fv.IsNil() {
+						fv.Set(reflect.New(ft))
+					}
+					fv = fv.Elem()
+				}
+				if err := d.structValue(m, fv, path); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+
+		if !field.IsExported() {
+			continue
+		}
+
+		name := tag
+		if name == "" {
+			name = field.Name
+		}
+		key, ok := lookupKey(m, name)
+		if !ok {
+			continue
+		}
+		if err := d.value(m[key], v.Field(i), joinPath(path, key)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// lookupKey finds the map key matching name, preferring an exact match over
+// a case-insensitive one. Among keys differing only by case, the first one
+// in sorted order is picked.
+func lookupKey(m map[string]any, name string) (string, bool) {
+	if _, ok := m[name]; ok {
+		return name, true
+	}
+	for _, k := range sortedKeys(m) {
+		if strings.EqualFold(k, name) {
+			return k, true
+		}
+	}
+	return "", false
+}
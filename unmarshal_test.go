@@ -0,0 +1,166 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type unmarshalAdmin struct {
+	Username string `config:"username"`
+	Password string `config:"password"`
+}
+
+type unmarshalBase struct {
+	Key8 string `config:"key8"`
+}
+
+type unmarshalMap struct {
+	unmarshalBase
+	Key0    bool    `config:"key0"`
+	Key2    bool    `config:"key2"`
+	Key4    float32 `config:"key4"`
+	Key6    int64   `config:"key6"`
+	Key7    uint    `config:"key7"`
+	Missing string  `config:"missing"`
+	Skipped string  `config:"-"`
+}
+
+type unmarshalConfig struct {
+	Server []string         `config:"server"`
+	Admin  []unmarshalAdmin `config:"admin"`
+	First  *unmarshalAdmin
+}
+
+func TestUnmarshal(t *testing.T) {
+	cfg, err := ParseYaml(yamlString)
+	assert.NoError(t, err)
+
+	var m unmarshalMap
+	m.Missing = "untouched"
+	assert.NoError(t, cfg.Unmarshal("map", &m))
+	assert.Equal(t, "value8", m.Key8)
+	assert.True(t, m.Key0)
+	assert.True(t, m.Key2)
+	assert.Equal(t, float32(4.2), m.Key4)
+	assert.Equal(t, int64(42), m.Key6)
+	assert.Equal(t, uint(42), m.Key7)
+	assert.Equal(t, "untouched", m.Missing)
+	assert.Empty(t, m.Skipped)
+
+	_ = cfg.Set("config.first", map[string]any{"username": "susie", "password": "derkins"})
+	var c unmarshalConfig
+	assert.NoError(t, cfg.Unmarshal("config", &c))
+	assert.Equal(t, []string{"www.google.com", "www.cnn.com", "www.example.com"}, c.Server)
+	assert.Equal(t, []unmarshalAdmin{{"calvin", "yukon"}, {"hobbes", "tuna"}}, c.Admin)
+	if assert.NotNil(t, c.First) {
+		assert.Equal(t, "susie", c.First.Username)
+	}
+
+	var users map[string]string
+	assert.NoError(t, cfg.Unmarshal("config.admin.1", &users))
+	assert.Equal(t, map[string]string{"username": "hobbes", "password": "tuna"}, users)
+
+	var list []any
+	assert.NoError(t, cfg.Unmarshal("list", &list))
+	assert.Len(t, list, 9)
+}
+
+func TestUnmarshalFromEnvStrings(t *testing.T) {
+	cfg, err := ParseYaml(`
+server:
+  port: 8080
+  debug: false
+`)
+	assert.NoError(t, err)
+	_ = cfg.Set("server.port", "9000")
+	_ = cfg.Set("server.debug", "true")
+
+	var srv struct {
+		Port  uint16
+		Debug bool
+	}
+	assert.NoError(t, cfg.Unmarshal("server", &srv))
+	assert.Equal(t, uint16(9000), srv.Port)
+	assert.True(t, srv.Debug)
+}
+
+func TestUnmarshalDurationAndTime(t *testing.T) {
+	cfg, err := ParseYaml(`
+server:
+  timeout: 1m
+  idle: 30
+  started: 2024-03-01T10:30:00Z
+`)
+	assert.NoError(t, err)
+
+	var srv struct {
+		Timeout time.Duration
+		Idle    *time.Duration
+		Started time.Time
+	}
+	assert.NoError(t, cfg.Unmarshal("server", &srv))
+	assert.Equal(t, time.Minute, srv.Timeout)
+	if assert.NotNil(t, srv.Idle) {
+		assert.Equal(t, 30*time.Second, *srv.Idle)
+	}
+	assert.Equal(t, 2024, srv.Started.Year())
+}
+
+func TestUnmarshalErrors(t *testing.T) {
+	cfg, err := ParseYaml(yamlString)
+	assert.NoError(t, err)
+
+	var m struct {
+		Key8 int `config:"key8"`
+	}
+	err = cfg.Unmarshal("map", &m)
+	assert.ErrorContains(t, err, `"map.key8"`)
+
+	var admins []struct {
+		Username int
+	}
+	err = cfg.Unmarshal("config.admin", &admins)
+	assert.ErrorContains(t, err, `"config.admin.0.username"`)
+
+	var small struct {
+		Key6 int8 `config:"key6"`
+		Key4 uint `config:"key4"`
+	}
+	err = cfg.Unmarshal("map", &small)
+	assert.ErrorContains(t, err, `"map.key4"`)
+
+	assert.Error(t, cfg.Unmarshal("map", m))
+	assert.Error(t, cfg.Unmarshal("map.undefined", &m))
+}
+
+func TestUnmarshalCopiesInterfaces(t *testing.T) {
+	cfg := NewSafe(&Config{Root: map[string]any{
+		"server": map[string]any{"port": 8080},
+		"ids":    []any{1, 2},
+	}})
+
+	var out struct {
+		Server any
+		IDs    any `config:"ids"`
+	}
+	assert.NoError(t, cfg.Unmarshal("", &out))
+	out.Server.(map[string]any)["port"] = 1
+	out.IDs.([]any)[0] = 3
+	assert.Equal(t, 8080, cfg.UInt("server.port"))
+	assert.Equal(t, 1, cfg.UInt("ids.0"))
+}
+
+func TestUnmarshalCaseClash(t *testing.T) {
+	cfg := &Config{Root: map[string]any{"PORT": 1, "Port": 2, "pOrt": 3}}
+	for i := 0; i < 20; i++ {
+		var out struct{ PoRT int }
+		assert.NoError(t, cfg.Unmarshal("", &out))
+		assert.Equal(t, 1, out.PoRT)
+	}
+}
@@ -0,0 +1,44 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"reflect"
+)
+
+// Value returns the value at the given dotted path converted to T.
+//
+// Any type supported by Unmarshal() can be requested, including sized
+// integers and floats, slices such as []string or []int, maps such as
+// map[string]string, structs and types implementing
+// encoding.TextUnmarshaler. Scalars follow the conversion rules of Int(),
+// Bool(), Float64() and String().
+//
+// Example:
+//
+//	port, err := config.Value[uint16](cfg, "server.port")
+//	hosts, err := config.Value[[]string](cfg, "database.hosts")
+func Value[T any](c *Config, path string) (T, error) {
+	var out T
+	if err := c.decode("value", path, reflect.ValueOf(&out).Elem()); err != nil {
+		var zero T
+		return zero, err
+	}
+	return out, nil
+}
+
+// ValueOr returns the value at the given dotted path converted to T, or def
+// if the path doesn't exist or the value can't be converted.
+//
+// Example:
+//
+//	limit := config.ValueOr[int64](cfg, "upload.limit", 1<<20)
+func ValueOr[T any](c *Config, path string, def T) T {
+	value, err := Value[T](c, path)
+	if err != nil {
+		return def
+	}
+	return value
+}
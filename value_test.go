@@ -0,0 +1,116 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// logLevel implements encoding.TextUnmarshaler.
+type logLevel int
+
+func (l *logLevel) UnmarshalText(text []byte) error {
+	switch strings.ToLower(string(text)) {
+	case "debug":
+		*l = 0
+	case "info":
+		*l = 1
+	default:
+		return fmt.Errorf("unknown level %q", text)
+	}
+	return nil
+}
+
+func TestValue(t *testing.T) {
+	cfg, err := ParseYaml(yamlString)
+	assert.NoError(t, err)
+
+	i64, err := Value[int64](cfg, "map.key7")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(42), i64)
+
+	u, err := Value[uint](cfg, "map.key6")
+	assert.NoError(t, err)
+	assert.Equal(t, uint(42), u)
+
+	u64, err := Value[uint64](cfg, "list.7")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(43), u64)
+
+	f32, err := Value[float32](cfg, "map.key5")
+	assert.NoError(t, err)
+	assert.Equal(t, float32(4.2), f32)
+
+	b, err := Value[bool](cfg, "map.key2")
+	assert.NoError(t, err)
+	assert.True(t, b)
+
+	servers, err := Value[[]string](cfg, "config.server")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"www.google.com", "www.cnn.com", "www.example.com"}, servers)
+
+	ints, err := Value[[]int](cfg, "list.6")
+	assert.ErrorIs(t, err, ErrTypeMismatch)
+	assert.Nil(t, ints)
+
+	admin, err := Value[map[string]string](cfg, "config.admin.0")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"username": "calvin", "password": "yukon"}, admin)
+
+	_, err = Value[int](cfg, "map.key8")
+	var pathErr *PathError
+	if assert.ErrorAs(t, err, &pathErr) {
+		assert.Equal(t, "value", pathErr.Op)
+		assert.Equal(t, "map.key8", pathErr.Path)
+	}
+	_, err = Value[uint](cfg, "map.undefined")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestValueSlicesAndText(t *testing.T) {
+	cfg, err := ParseYaml(`
+ports: [80, "443", 8080]
+ip: 192.168.1.1
+level: info
+levels: [debug, info]
+bad: verbose
+`)
+	assert.NoError(t, err)
+
+	ports, err := Value[[]int](cfg, "ports")
+	assert.NoError(t, err)
+	assert.Equal(t, []int{80, 443, 8080}, ports)
+
+	ip, err := Value[net.IP](cfg, "ip")
+	assert.NoError(t, err)
+	assert.Equal(t, "192.168.1.1", ip.String())
+
+	level, err := Value[logLevel](cfg, "level")
+	assert.NoError(t, err)
+	assert.Equal(t, logLevel(1), level)
+
+	levels, err := Value[[]logLevel](cfg, "levels")
+	assert.NoError(t, err)
+	assert.Equal(t, []logLevel{0, 1}, levels)
+
+	_, err = Value[logLevel](cfg, "bad")
+	assert.ErrorIs(t, err, ErrTypeMismatch)
+}
+
+func TestValueOr(t *testing.T) {
+	cfg, err := ParseYaml(yamlString)
+	assert.NoError(t, err)
+
+	assert.Equal(t, int64(42), ValueOr[int64](cfg, "map.key6", 7))
+	assert.Equal(t, int64(7), ValueOr[int64](cfg, "map.undefined", 7))
+	assert.Equal(t, uint(7), ValueOr[uint](cfg, "map.key8", 7))
+	assert.Equal(t, []string{"a"}, ValueOr(cfg, "map.undefined", []string{"a"}))
+	assert.Equal(t, float32(4.3), ValueOr[float32](cfg, "list.4", 0))
+}
@@ -0,0 +1,204 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Watcher reloads a configuration file when its contents change on disk.
+//
+// Changes are detected by polling the file contents, so it works on any
+// filesystem, including Kubernetes ConfigMap mounts where the file is
+// replaced by swapping a symlink. A reload only replaces the current
+// configuration if the new contents parse successfully; otherwise the
+// previous configuration is kept and the error is reported.
+type Watcher struct {
+	filename string
+	parse    func(filename string) (*Config, error)
+
+	mu       sync.RWMutex
+	cfg      *Config
+	lastErr  error
+	onChange []func(old, new *Config)
+	onError  []func(err error)
+
+	reloadMu sync.Mutex
+	sum      [sha256.Size]byte
+
+	stop      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// Watch loads filename with the given parse function, such as ParseYamlFile
+// or ParseJsonFile, and polls it for changes every interval.
+//
+// The returned Watcher must be closed with Close() when no longer needed.
+//
+// Example:
+//
+//	w, err := config.Watch("config.yml", config.ParseYamlFile, 5*time.Second)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	defer w.Close()
+//
+//	w.OnChange(func(old, new *config.Config) {
+//	    log.Printf("log level is now %s", new.UString("log.level"))
+//	})
+func Watch(filename string, parse func(filename string) (*Config, error), interval time.Duration) (*Watcher, error) {
+	if interval <= 0 {
+		return nil, errors.New("watch: interval must be positive")
+	}
+
+	w := &Watcher{
+		filename: filename,
+		parse:    parse,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+
+	data, err := os.ReadFile(filepath.Clean(filename))
+	if err != nil {
+		return nil, err
+	}
+	if w.cfg, err = parse(filename); err != nil {
+		return nil, err
+	}
+	w.sum = sha256.Sum256(data)
+
+	go w.run(interval)
+	return w, nil
+}
+
+// Config returns the most recently loaded configuration.
+func (w *Watcher) Config() *Config {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.cfg
+}
+
+// Error returns the error of the last reload, or nil if it succeeded.
+func (w *Watcher) Error() error {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.lastErr
+}
+
+// OnChange registers a callback invoked with the previous and the new
+// configuration after every successful reload.
+func (w *Watcher) OnChange(fn func(old, new *Config)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.onChange = append(w.onChange, fn)
+}
+
+// OnError registers a callback invoked when reading or parsing the file
+// fails. The previous configuration stays in place.
+func (w *Watcher) OnError(fn func(err error)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.onError = append(w.onError, fn)
+}
+
+// Reload checks the file immediately and reloads it if its contents changed.
+// It is called periodically by the Watcher, but can also be used to force a
+// check, e.g. on SIGHUP. A file modified while it is being reloaded is left
+// to the next check. The error callbacks are notified once per failure, not
+// on every check while the file stays missing or invalid.
+func (w *Watcher) Reload() error {
+	w.reloadMu.Lock()
+	defer w.reloadMu.Unlock()
+
+	data, err := os.ReadFile(filepath.Clean(w.filename))
+	if err != nil {
+		// Forget the contents, so that the file is reloaded when it
+		// comes back.
+		w.sum = [sha256.Size]byte{}
+		return w.fail(err)
+	}
+	sum := sha256.Sum256(data)
+	if bytes.Equal(sum[:], w.sum[:]) {
+		return nil
+	}
+
+	cfg, err := w.parse(w.filename)
+	// The parse function reads the file on its own: if it changed since it
+	// was hashed, the result may not match sum, so leave it to the next
+	// check.
+	if again, readErr := os.ReadFile(filepath.Clean(w.filename)); readErr != nil || sha256.Sum256(again) != sum {
+		return nil
+	}
+	// Remember the contents even if they fail to parse, so that the
+	// same error is reported only once.
+	w.sum = sum
+	if err != nil {
+		return w.fail(err)
+	}
+
+	w.mu.Lock()
+	old := w.cfg
+	w.cfg = cfg
+	w.lastErr = nil
+	callbacks := w.onChange
+	w.mu.Unlock()
+
+	for _, fn := range callbacks {
+		fn(old, cfg)
+	}
+	return nil
+}
+
+// Close stops watching the file.
+func (w *Watcher) Close() error {
+	w.closeOnce.Do(func() {
+		close(w.stop)
+	})
+	<-w.done
+	return nil
+}
+
+// fail records a reload error and notifies the error callbacks, unless the
+// previous reload failed with the same error.
+func (w *Watcher) fail(err error) error {
+	w.mu.Lock()
+	repeated := w.lastErr != nil && w.lastErr.Error() == err.Error()
+	w.lastErr = err
+	callbacks := w.onError
+	w.mu.Unlock()
+
+	if repeated {
+		return err
+	}
+
+	for _, fn := range callbacks {
+		fn(err)
+	}
+	return err
+}
+
+// run polls the file until the Watcher is closed.
+func (w *Watcher) run(interval time.Duration) {
+	defer close(w.done)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-w.stop:
+			return
+		case <-ticker.C:
+			_ = w.Reload()
+		}
+	}
+}
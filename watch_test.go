@@ -0,0 +1,151 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatch(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "config.yml")
+	require.NoError(t, os.WriteFile(filename, []byte("log:\n  level: info\n"), 0o600))
+
+	w, err := Watch(filename, ParseYamlFile, 10*time.Millisecond)
+	require.NoError(t, err)
+	defer w.Close()
+	assert.Equal(t, "info", w.Config().UString("log.level"))
+
+	changes := make(chan [2]*Config, 1)
+	w.OnChange(func(old, new *Config) {
+		changes <- [2]*Config{old, new}
+	})
+	errs := make(chan error, 1)
+	w.OnError(func(err error) {
+		errs <- err
+	})
+
+	require.NoError(t, os.WriteFile(filename, []byte("log:\n  level: debug\n"), 0o600))
+	select {
+	case change := <-changes:
+		assert.Equal(t, "info", change[0].UString("log.level"))
+		assert.Equal(t, "debug", change[1].UString("log.level"))
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for reload")
+	}
+	assert.Equal(t, "debug", w.Config().UString("log.level"))
+
+	// An invalid file keeps the previous configuration.
+	require.NoError(t, os.WriteFile(filename, []byte("log: [\n"), 0o600))
+	select {
+	case err := <-errs:
+		assert.Error(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for reload error")
+	}
+	assert.Error(t, w.Error())
+	assert.Equal(t, "debug", w.Config().UString("log.level"))
+}
+
+func TestWatchSymlinkSwap(t *testing.T) {
+	// Mimic the layout of a Kubernetes ConfigMap volume, where config.yml
+	// points into ..data, which is atomically swapped to a new directory.
+	dir := t.TempDir()
+	for _, v := range []string{"v1", "v2"} {
+		require.NoError(t, os.Mkdir(filepath.Join(dir, v), 0o700))
+		content := "version: " + v + "\n"
+		require.NoError(t, os.WriteFile(filepath.Join(dir, v, "config.yml"), []byte(content), 0o600))
+	}
+	require.NoError(t, os.Symlink("v1", filepath.Join(dir, "..data")))
+	require.NoError(t, os.Symlink(filepath.Join("..data", "config.yml"), filepath.Join(dir, "config.yml")))
+
+	w, err := Watch(filepath.Join(dir, "config.yml"), ParseYamlFile, time.Hour)
+	require.NoError(t, err)
+	defer w.Close()
+	assert.Equal(t, "v1", w.Config().UString("version"))
+
+	require.NoError(t, os.Symlink("v2", filepath.Join(dir, "..data_tmp")))
+	require.NoError(t, os.Rename(filepath.Join(dir, "..data_tmp"), filepath.Join(dir, "..data")))
+
+	assert.NoError(t, w.Reload())
+	assert.NoError(t, w.Error())
+	assert.Equal(t, "v2", w.Config().UString("version"))
+}
+
+func TestWatchErrors(t *testing.T) {
+	_, err := Watch("testdata/missing.yml", ParseYamlFile, time.Second)
+	assert.Error(t, err)
+
+	_, err = Watch("testdata/default.yml", ParseYamlFile, 0)
+	assert.Error(t, err)
+}
+
+func TestWatchErrorsReportedOnce(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "config.yml")
+	require.NoError(t, os.WriteFile(filename, []byte("version: 1\n"), 0o600))
+
+	w, err := Watch(filename, ParseYamlFile, time.Hour)
+	require.NoError(t, err)
+	defer w.Close()
+	var errs []error
+	w.OnError(func(err error) {
+		errs = append(errs, err)
+	})
+	changes := 0
+	w.OnChange(func(old, new *Config) {
+		changes++
+	})
+
+	require.NoError(t, os.Remove(filename))
+	for i := 0; i < 3; i++ {
+		assert.Error(t, w.Reload())
+	}
+	assert.Len(t, errs, 1)
+
+	require.NoError(t, os.WriteFile(filename, []byte("version: [\n"), 0o600))
+	assert.Error(t, w.Reload())
+	assert.NoError(t, w.Reload())
+	assert.Error(t, w.Error())
+	assert.Len(t, errs, 2)
+
+	// The same contents as before the file went missing are reloaded.
+	require.NoError(t, os.WriteFile(filename, []byte("version: 1\n"), 0o600))
+	assert.NoError(t, w.Reload())
+	assert.NoError(t, w.Error())
+	assert.Equal(t, 1, changes)
+	assert.Equal(t, 1, w.Config().UInt("version"))
+}
+
+func TestWatchChangedDuringParse(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "config.yml")
+	require.NoError(t, os.WriteFile(filename, []byte("version: 1\n"), 0o600))
+
+	writes := 0
+	parse := func(name string) (*Config, error) {
+		cfg, err := ParseYamlFile(name)
+		if writes == 1 {
+			// Simulate a write racing with the reload.
+			writes++
+			require.NoError(t, os.WriteFile(filename, []byte("version: 3\n"), 0o600))
+		}
+		return cfg, err
+	}
+	w, err := Watch(filename, parse, time.Hour)
+	require.NoError(t, err)
+	defer w.Close()
+
+	writes++
+	require.NoError(t, os.WriteFile(filename, []byte("version: 2\n"), 0o600))
+	assert.NoError(t, w.Reload())
+	assert.Equal(t, 1, w.Config().UInt("version"))
+
+	assert.NoError(t, w.Reload())
+	assert.Equal(t, 3, w.Config().UInt("version"))
+}